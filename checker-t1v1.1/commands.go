@@ -0,0 +1,314 @@
+package main
+
+import (
+	"checker-pa/src/checker-modules"
+	"checker-pa/src/display"
+	"checker-pa/src/manager"
+	"checker-pa/src/menu"
+	"checker-pa/src/report"
+	"checker-pa/src/utils"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+type command struct {
+	name        string
+	usage       string
+	description string
+	// Whether the global flags can follow the command
+	sharesFlags bool
+	run         func(args []string)
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"run", "run", "Run the checker headless, or with -i in the interactive interface", true, runCommand},
+		{"list", "list", "Print the configured tests and modules", true, listCommand},
+		{"show", "show <test>", "Run the checker and print the diff and memory report of a test", true, showCommand},
+		{"watch", "watch", "Rerun the checker whenever the executable changes", true, watchCommand},
+		{"doctor", "doctor", "Check the dependencies and the paths", true, doctorCommand},
+		{"init", "init", "Write a starter config.json and module_config.json", false, initCommand},
+		{"convert", "convert <in> [out]", "Translate a config between JSON, YAML and TOML, by extension", false, convertCommand},
+	}
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+
+	return nil
+}
+
+func fatalIfErr(err error) {
+	if err != nil {
+		utils.Fatal("FATAL ERROR DETECTED! " + err.Error() + "\n ABORTING!")
+	}
+}
+
+// Prints the module results and the summary
+func dumpResults(m *manager.Manager) {
+	utils.Log("Basic Display")
+
+	for _, module := range m.Modules {
+		module.Dump()
+	}
+
+	m.BasicSummary("")
+}
+
+func writeReports(m *manager.Manager) {
+	if sarifPath != "" {
+		fatalIfErr(report.WriteSARIF(sarifPath, m.Modules))
+		utils.Log("SARIF log written to " + sarifPath)
+	}
+
+	if jsonPath != "" {
+		fatalIfErr(report.WriteJSON(jsonPath, m))
+		utils.Log("JSON report written to " + jsonPath)
+	}
+
+	if junitPath != "" {
+		fatalIfErr(report.WriteJUnit(junitPath, m))
+		utils.Log("JUnit report written to " + junitPath)
+	}
+
+	if htmlPath != "" {
+		fatalIfErr(report.WriteHTML(htmlPath, m))
+		utils.Log("HTML report written to " + htmlPath)
+	}
+}
+
+func runCommand(_ []string) {
+	m := newManager()
+
+	if useInteractive {
+
+		utils.Log("Interactive Display")
+		d := display.NewDisplay()
+
+		go func() {
+			err := m.Run()
+			if err != nil {
+				d.App.Stop()
+				utils.Fatal("FATAL ERROR DETECTED! " + err.Error() + "\n ABORTING!")
+			}
+		}()
+
+		go m.Watch(nil)
+
+		mn := menu.Menu{Display: d, Manager: m}
+
+		mn.Launch()
+
+		d.Enable()
+
+		return
+	}
+
+	fatalIfErr(m.Run())
+
+	dumpResults(m)
+	writeReports(m)
+
+	os.Exit(m.ExitCode(minScore))
+}
+
+func listCommand(_ []string) {
+	m := newManager()
+
+	fmt.Printf("Config: %s\n\n", utils.Config.ModuleConfigSource)
+	fmt.Printf("===== Tests - %d =====\n\n", len(utils.Config.Tests))
+	for _, test := range utils.Config.Tests {
+		fmt.Printf("%-12s %-12s %3d  %s\n", test.File, test.DisplayName, test.Score, strings.Join(test.Args, " "))
+	}
+	for _, warning := range utils.DiscoveryWarnings {
+		fmt.Println(color.YellowString("warning: " + warning))
+	}
+
+	fmt.Printf("\n===== Modules - %d =====\n\n", len(m.Modules))
+	for _, module := range m.Modules {
+		status := color.GreenString("enabled")
+		switch module.GetStatus() {
+		case checkermodules.Disabled:
+			status = module.GetStatus().String()
+		case checkermodules.DependencyFail:
+			status = color.RedString("missing %s", strings.Join(module.GetDependencies(), ", "))
+		}
+
+		fmt.Printf("%-8s %s\n", module.GetName(), status)
+	}
+}
+
+func showCommand(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: show <test>")
+		os.Exit(manager.ExitInfrastructure)
+	}
+
+	m := newManager()
+
+	index := utils.FindTest(args[0])
+	if index == -1 {
+		utils.Fatal("no test named " + args[0] + ", see the list command")
+	}
+
+	test := utils.Config.Tests[index]
+
+	// No need to run the other tests
+	fatalIfErr(utils.SetTestFilter([]string{test.File}))
+	fatalIfErr(m.Run())
+
+	shown := false
+
+	for _, module := range m.Modules {
+		viewer, ok := module.(checkermodules.TestViewer)
+		if !ok {
+			continue
+		}
+
+		if str, ok := viewer.ShowTest(index); ok {
+			fmt.Printf("===== %s - %s =====\n\n", module.GetName(), test.DisplayName)
+			fmt.Println(str)
+			fmt.Println()
+			shown = true
+		}
+	}
+
+	if !shown {
+		fmt.Println("No results for " + test.DisplayName + ", check the log for errors.")
+	}
+}
+
+func watchCommand(_ []string) {
+	m := newManager()
+
+	fatalIfErr(m.Run())
+	dumpResults(m)
+	writeReports(m)
+
+	fmt.Println("Watching " + utils.Config.ExecutablePath + " for changes, press Ctrl+C to exit")
+
+	m.Watch(func() {
+		dumpResults(m)
+		writeReports(m)
+	})
+}
+
+func doctorCommand(_ []string) {
+	m := newManager()
+
+	failed := false
+
+	for _, diagnosis := range m.Doctor() {
+		switch {
+		case diagnosis.Err == nil:
+			fmt.Printf("%s %s\n", color.GreenString("[OK]  "), diagnosis.Name)
+		case diagnosis.Warning:
+			fmt.Printf("%s %s: %s\n", color.YellowString("[WARN]"), diagnosis.Name, diagnosis.Err)
+		default:
+			fmt.Printf("%s %s: %s\n", color.RedString("[ERR] "), diagnosis.Name, diagnosis.Err)
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(manager.ExitInfrastructure)
+	}
+}
+
+// Writes the file unless it already exists
+func writeStarter(path string, content string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		fmt.Println(path + " already exists, skipped (use -force to overwrite)")
+		return nil
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil { //nolint:gosec
+		return err
+	}
+
+	fmt.Println("Created " + path)
+	return nil
+}
+
+func initCommand(args []string) {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	force := fs.Bool("force", false, "Overwrite the existing files")
+	_ = fs.Parse(args)
+
+	starters := []struct{ path, content string }{
+		{utils.UserConfigPath, defaultUserConfigStr},
+		{filepath.Join(filepath.Dir(utils.UserConfigPath), utils.ModuleConfigName), moduleConfigStr},
+	}
+
+	for _, starter := range starters {
+		if err := writeStarter(starter.path, starter.content, *force); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(manager.ExitInfrastructure)
+		}
+	}
+}
+
+func convertCommand(args []string) {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	force := fs.Bool("force", false, "Overwrite the output file")
+	_ = fs.Parse(args)
+
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fmt.Fprintln(os.Stderr, "usage: convert [-force] <in> [out], prints to the standard output without out")
+		os.Exit(manager.ExitInfrastructure)
+	}
+
+	in := fs.Arg(0)
+	data, err := os.ReadFile(in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(manager.ExitInfrastructure)
+	}
+
+	out := fs.Arg(1)
+	to := utils.FormatOf(out)
+	if out == "" {
+		// Without an output, from JSON to YAML and from the others to JSON
+		to = utils.FormatJSON
+		if utils.FormatOf(in) == utils.FormatJSON {
+			to = utils.FormatYAML
+		}
+	}
+
+	converted, err := utils.ConvertConfig(string(data), utils.FormatOf(in), to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", in, err)
+		os.Exit(manager.ExitInfrastructure)
+	}
+
+	if out == "" {
+		fmt.Print(converted)
+		return
+	}
+
+	if _, err := os.Stat(out); err == nil && !*force {
+		fmt.Fprintln(os.Stderr, out+" already exists (use -force to overwrite)")
+		os.Exit(manager.ExitInfrastructure)
+	}
+
+	if err := os.WriteFile(out, []byte(converted), 0644); err != nil { //nolint:gosec
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(manager.ExitInfrastructure)
+	}
+
+	fmt.Printf("Converted %s to %s\n", in, out)
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
-	"checker-pa/src/display"
 	"checker-pa/src/manager"
-	"checker-pa/src/menu"
 	"checker-pa/src/utils"
 	_ "embed"
 	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
 )
 
 //go:embed res/config/module_config.json
@@ -16,15 +18,46 @@ var moduleConfigStr string
 var defaultUserConfigStr string
 
 var useInteractive bool
+var sarifPath string
+var jsonPath string
+var junitPath string
+var htmlPath string
+var minScore int
+var testTimeout time.Duration
+var testFilter string
+var moduleFilter string
+var moduleConfigPath string
 
 func init() {
 	flag.BoolVar(&useInteractive, "i", false, "Interactive mode")
+	flag.StringVar(&sarifPath, "sarif", "", "Export the module issues as a SARIF log")
+	flag.StringVar(&jsonPath, "json", "", "Export the whole run as a JSON report")
+	flag.StringVar(&junitPath, "junit", "", "Export the test results as a JUnit XML file")
+	flag.StringVar(&htmlPath, "html", "", "Export the whole run as a self-contained HTML page")
+	flag.IntVar(&minScore, "min-score", 0, "Exit with a failure when the total score is lower")
+	flag.DurationVar(&testTimeout, "timeout", 0, "Kill the tests running longer than this (e.g. 5s)")
+	flag.StringVar(&testFilter, "t", "", "Run only the matching tests, by file or display name (e.g. data13,data2*)")
+	flag.StringVar(&moduleConfigPath, "config", "", "Module config to use instead of the discovered or embedded one")
+	flag.StringVar(&moduleFilter, "m", "", "Run only these modules, by name or config key (e.g. MEMORY,style_checker)")
+	flag.Func("set", "Override a user config key for this run, repeatable (e.g. executable_path=./a.out)", utils.SetFlagOverride)
+
+	flag.Usage = usage
 }
 
-func main() {
-	flag.Parse()
+func usage() {
+	out := flag.CommandLine.Output()
+
+	fmt.Fprintf(out, "Usage: %s [command] [flags]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-12s %s\n", cmd.usage, cmd.description)
+	}
+	fmt.Fprintf(out, "\nWithout a command the checker runs once, like run.\n\nFlags:\n")
+	flag.PrintDefaults()
+}
 
-	err := utils.InitConfig(defaultUserConfigStr, moduleConfigStr)
+// Loads the configs and creates the manager, the common setup of the commands
+func newManager() *manager.Manager {
+	err := utils.InitConfig(defaultUserConfigStr, moduleConfigStr, moduleConfigPath)
 	if err != nil {
 		utils.Fatal("FATAL ERROR DETECTED! " + err.Error() + "\n ABORTING!")
 	}
@@ -35,41 +68,60 @@ func main() {
 		utils.Fatal("FATAL ERROR DETECTED! " + err.Error() + "\n ABORTING!")
 	}
 
-	// TODO?: make this return an error as well?
-	// m.Check()
+	m.Timeout = testTimeout
 
-	if useInteractive {
+	if err := utils.SetTestFilter(splitList(testFilter)); err != nil {
+		utils.Fatal("FATAL ERROR DETECTED! " + err.Error() + "\n ABORTING!")
+	}
 
-		utils.Log("Interactive Display")
-		d := display.NewDisplay()
+	if err := m.SetModuleFilter(splitList(moduleFilter)); err != nil {
+		utils.Fatal("FATAL ERROR DETECTED! " + err.Error() + "\n ABORTING!")
+	}
+
+	return m
+}
 
-		go func() {
-			err := m.Run()
-			if err != nil {
-				d.App.Stop()
-				utils.Fatal("FATAL ERROR DETECTED! " + err.Error() + "\n ABORTING!")
-			}
-		}()
+// Splits a comma separated flag value, dropping the empty items
+func splitList(value string) []string {
+	var items []string
 
-		mn := menu.Menu{Display: d, Manager: m}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
 
-		mn.Launch()
+	return items
+}
 
-		d.Enable()
+func main() {
+	// Started by the manager to apply the resource limits of a test, never returns
+	manager.RunLimitHelper()
 
-	} else {
-		err = m.Run()
-		if err != nil {
-			utils.Fatal("FATAL ERROR DETECTED! " + err.Error() + "\n ABORTING!")
-		}
+	flag.Parse()
 
-		utils.Log("Basic Display")
+	name := "run"
+	args := flag.Args()
 
-		for _, module := range m.Modules {
-			module.Dump()
-		}
+	if len(args) > 0 {
+		name = args[0]
+		args = args[1:]
+	}
 
-		m.BasicSummary("")
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		flag.Usage()
+		os.Exit(manager.ExitInfrastructure)
+	}
 
+	// The flags can also follow the command
+	if cmd.sharesFlags {
+		if err := flag.CommandLine.Parse(args); err != nil {
+			os.Exit(manager.ExitInfrastructure)
+		}
+		args = flag.Args()
 	}
+
+	cmd.run(args)
 }
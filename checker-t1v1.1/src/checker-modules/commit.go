@@ -5,19 +5,24 @@ import (
 	"checker-pa/src/utils"
 	"errors"
 	"fmt"
-	"os"
 	"os/exec"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rivo/tview"
 )
 
 type CommitChecker struct {
 	ModuleOutput
-	commits []string
-	score   int
-	status  ModuleStatus
+	commits   []commitInfo
+	lateness  time.Duration
+	deadline  string // summary of the last commits around the deadline
+	gitignore string // suggested .gitignore for the committed artifacts
+	score     int
+	status    ModuleStatus
 }
 
 var ErrNotFound = errors.New(" The checker couldn't find git on your system. Are you sure it's installed?")
@@ -53,6 +58,10 @@ func (cc *CommitChecker) GetResult() string {
 	return strconv.Itoa(cc.score)
 }
 
+func (cc *CommitChecker) Report() ModuleReport {
+	return newModuleReport(cc, cc.score)
+}
+
 func (cc *CommitChecker) Panic() {
 	cc.status = Panic
 }
@@ -63,10 +72,20 @@ func (cc *CommitChecker) Reset() {
 	}
 	cc.commits = nil
 	cc.Issues = nil
+	cc.lateness = 0
+	cc.deadline = ""
+	cc.gitignore = ""
 	cc.score = 0
 	cc.status = Queued
 }
 
+func (cc *CommitChecker) Lateness() time.Duration {
+	if cc.status != Ready {
+		return 0
+	}
+	return cc.lateness
+}
+
 func (cc *CommitChecker) Score() int {
 	return int(float32(cc.score) * utils.Config.CommitChecker.Grade)
 }
@@ -86,6 +105,7 @@ func (cc *CommitChecker) Display(d *display.Display) {
 	if len(cc.Issues) == 0 {
 		d.Println("No issues found!")
 		d.Println(fmt.Sprintf("Got %d/%d points! Congrats :)", cc.Score(), cc.Score()))
+		cc.displayCommits(d)
 		return
 	}
 
@@ -105,6 +125,27 @@ func (cc *CommitChecker) Display(d *display.Display) {
 	}
 
 	d.Println(fmt.Sprintf("The final score is %d/%d.", cc.Score(), int(utils.Config.CommitChecker.Grade*100)))
+
+	cc.displayCommits(d)
+}
+
+func (cc *CommitChecker) displayCommits(d *display.Display) {
+	if cc.deadline != "" {
+		d.Println("\n" + cc.deadline)
+	}
+
+	if cc.gitignore != "" {
+		d.Println("\nSuggested .gitignore, at the root of the repository:\n" + cc.gitignore)
+	}
+
+	if len(cc.commits) == 0 {
+		return
+	}
+
+	table := newCommitTable(cc.commits)
+	table.SetBorder(true).SetTitle("Commits")
+
+	d.CurrentContainer().AddPrimitive(table, true, 0, 1)
 }
 
 func (cc *CommitChecker) Dump() {
@@ -116,32 +157,210 @@ func (cc *CommitChecker) Dump() {
 	}
 
 	fmt.Println(cc.ModuleError.String())
+
+	if cc.deadline != "" {
+		fmt.Println(cc.deadline)
+	}
+
+	if cc.gitignore != "" {
+		fmt.Println("Suggested .gitignore, at the root of the repository:\n" + cc.gitignore + "\n")
+	}
+
+	if len(cc.commits) > 0 {
+		fmt.Println(commitTableString(cc.commits))
+	}
+
 	fmt.Println()
 }
 
+func (cc *CommitChecker) checkDeadline() {
+	deadline, err := utils.ParseDeadline(utils.Config.Deadline)
+	if err != nil {
+		cc.Issues = append(cc.Issues, ModuleIssue{Message: err.Error(), Critical: true})
+		return
+	}
+
+	before, after := lastSourceCommits(cc.commits, deadline)
+
+	str := strings.Builder{}
+	str.WriteString(fmt.Sprintf("Deadline: %s\n", deadline.Format("2006-01-02 15:04")))
+
+	if before != nil {
+		str.WriteString(fmt.Sprintf("Last source commit before the deadline: %s on %s\n",
+			before.shortHash, before.committed.Format("2006-01-02 15:04")))
+	}
+
+	if after != nil {
+		cc.lateness = after.committed.Sub(deadline)
+		str.WriteString(fmt.Sprintf("Last source commit after the deadline: %s on %s\n",
+			after.shortHash, after.committed.Format("2006-01-02 15:04")))
+
+		cc.Issues = append(cc.Issues, ModuleIssue{
+			Message:  fmt.Sprintf("Late submission: commit %s was made %s after the deadline", after.shortHash, cc.lateness.Round(time.Minute)),
+			RuleID:   "late-submission",
+			Severity: "warning",
+		})
+	}
+
+	cc.deadline = str.String()
+}
+
+// Matches "<type>(<scope>)!: <subject>", the scope and the "!" are optional
+var conventionalRegex = regexp.MustCompile(`^([\w-]+)(?:\(([^)]*)\))?!?:(.*)$`)
+
+// Used when the config doesn't provide a policy
+var defaultCommitPolicy = utils.CommitPolicy{MinSubject: 10}
+
+type commitViolation struct {
+	rule    string
+	message string
+}
+
 // receives the commit line without the commit hash
-func checkCommits(line string) error {
-	if !strings.Contains(line, ":") {
-		return errors.New("invalid format! Hint, the format is: <type of commit>: <message>)")
+func checkCommit(line string, policy *utils.CommitPolicy) []commitViolation {
+	match := conventionalRegex.FindStringSubmatch(line)
+	if match == nil {
+		return []commitViolation{{"format", "invalid format! Hint, the format is: <type of commit>: <message>"}}
+	}
+
+	var violations []commitViolation
+
+	commitType, scope := match[1], match[2]
+	subject := strings.TrimSpace(match[3])
+
+	if len(policy.Types) > 0 && !slices.Contains(policy.Types, commitType) {
+		violations = append(violations, commitViolation{"type",
+			fmt.Sprintf("\"%s\" is not an allowed type (%s)", commitType, strings.Join(policy.Types, ", "))})
+	}
+
+	if scope == "" && policy.RequireScope {
+		violations = append(violations, commitViolation{"scope", "the commit has no scope"})
+	} else if scope != "" && len(policy.Scopes) > 0 && !slices.Contains(policy.Scopes, scope) {
+		violations = append(violations, commitViolation{"scope",
+			fmt.Sprintf("\"%s\" is not an allowed scope (%s)", scope, strings.Join(policy.Scopes, ", "))})
+	}
+
+	if len(subject) < policy.MinSubject {
+		violations = append(violations, commitViolation{"subject-length",
+			fmt.Sprintf("the message is too short (%d < %d)", len(subject), policy.MinSubject)})
+	} else if policy.MaxSubject > 0 && len(subject) > policy.MaxSubject {
+		violations = append(violations, commitViolation{"subject-length",
+			fmt.Sprintf("the message is too long (%d > %d)", len(subject), policy.MaxSubject)})
+	}
+
+	normalized := strings.ToLower(strings.TrimRight(subject, ".!? "))
+	for _, forbidden := range policy.Forbidden {
+		if normalized == strings.ToLower(forbidden) {
+			violations = append(violations, commitViolation{"forbidden",
+				fmt.Sprintf("\"%s\" doesn't describe the change", subject)})
+			break
+		}
+	}
+
+	if policy.ImperativeMood {
+		if hint := imperativeHint(subject); hint != "" {
+			violations = append(violations, commitViolation{"imperative", hint})
+		}
+	}
+
+	return violations
+}
+
+// Past tenses and gerunds of the verbs commits usually start with, by their imperative.
+// A list rather than the suffixes, which would flag "need", "embed" or "bring"
+var nonImperativeVerbs = map[string]string{
+	"added": "add", "adding": "add",
+	"fixed": "fix", "fixing": "fix",
+	"updated": "update", "updating": "update",
+	"removed": "remove", "removing": "remove",
+	"deleted": "delete", "deleting": "delete",
+	"changed": "change", "changing": "change",
+	"created": "create", "creating": "create",
+	"implemented": "implement", "implementing": "implement",
+	"refactored": "refactor", "refactoring": "refactor",
+	"renamed": "rename", "renaming": "rename",
+	"moved": "move", "moving": "move",
+	"improved": "improve", "improving": "improve",
+	"cleaned": "clean", "cleaning": "clean",
+	"tested": "test", "testing": "test",
+	"merged": "merge", "merging": "merge",
+	"wrote": "write", "writing": "write",
+	"made": "make", "making": "make",
+	"used": "use", "using": "use",
+	"handled": "handle", "handling": "handle",
+	"replaced": "replace", "replacing": "replace",
+	"modified": "modify", "modifying": "modify",
+	"corrected": "correct", "correcting": "correct",
+	"finished": "finish", "finishing": "finish",
+	"started": "start", "starting": "start",
+	"solved": "solve", "solving": "solve",
+	"debugged": "debug", "debugging": "debug",
+	"optimized": "optimize", "optimizing": "optimize",
+	"formatted": "format", "formatting": "format",
+	"reverted": "revert", "reverting": "revert",
+	"documented": "document", "documenting": "document",
+	"simplified": "simplify", "simplifying": "simplify",
+	"ran": "run", "running": "run",
+	"freed": "free", "freeing": "free",
+	"allocated": "allocate", "allocating": "allocate",
+	"checked": "check", "checking": "check",
+	"edited": "edit", "editing": "edit",
+	"built": "build", "building": "build",
+	"uploaded": "upload", "uploading": "upload",
+	// Their past tense is the imperative
+	"reading": "read", "splitting": "split",
+}
+
+// Returns a hint when the subject starts with a past tense or a gerund of a common verb
+func imperativeHint(subject string) string {
+	fields := strings.Fields(subject)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	word := strings.ToLower(strings.TrimRight(fields[0], ":,."))
+
+	if imperative, ok := nonImperativeVerbs[word]; ok {
+		return fmt.Sprintf("use the imperative mood (\"%s\" instead of \"%s\")", imperative, word)
 	}
 
-	typeAndMessage := strings.SplitN(line, ":", 2)
-	message := strings.Trim(typeAndMessage[1], " ")
+	return ""
+}
+
+// Creates a git command running inside the source path
+func gitCommand(args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = utils.Config.SourcePath
+
+	return cmd
+}
+
+func gitLogArgs() []string {
+	config := utils.Config.CommitChecker
+
+	args := []string{"log", "--numstat", gitLogFormat}
 
-	//TODO: replace 10 later
-	if len(message) < 10 {
-		return errors.New("the message is too short")
+	if config.Since != "" {
+		args = append(args, "--since="+config.Since)
 	}
 
-	return nil
+	if config.Ref != "" {
+		args = append(args, config.Ref)
+	} else {
+		args = append(args, "--all")
+	}
+
+	args = append(args, "--")
+	args = append(args, config.Paths...)
+
+	return args
 }
 
 func (cc *CommitChecker) Run() {
 	cc.status = Running
 	defer func() { cc.status = Ready }()
 
-	args := []string{"log", "--oneline", "--all"}
-	cmd := exec.Command("git", args...)
+	cmd := gitCommand(gitLogArgs()...)
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -151,15 +370,20 @@ func (cc *CommitChecker) Run() {
 			return
 		}
 		// if the student didn't "git init" before, this will give an ambiguous error
-		_, newErr := os.Stat(".git")
-		if errors.Is(newErr, os.ErrNotExist) {
+		if gitCommand("rev-parse", "--git-dir").Run() != nil {
 			errMsg := "Couldn't find any commits, are you sure you ran 'git init' first?"
 			issue := ModuleIssue{Message: errMsg, Critical: true}
 			cc.Issues = append(cc.Issues, issue)
 			return
 		}
 
-		issue := ModuleIssue{Message: "CRITICAL ERROR! " + err.Error(), Critical: true}
+		errMsg := "CRITICAL ERROR! " + err.Error()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			errMsg += "\n" + strings.TrimSpace(string(exitErr.Stderr))
+		}
+
+		issue := ModuleIssue{Message: errMsg, Critical: true}
 		cc.Issues = append(cc.Issues, issue)
 		return
 	}
@@ -171,10 +395,10 @@ func (cc *CommitChecker) Run() {
 		return
 	}
 
-	lines := strings.Split(string(output), "\n")
+	commits, err := parseGitLog(string(output))
 
 	// sanity check, it shouldn't happen ... i hope
-	if len(lines) == 0 {
+	if err != nil || len(commits) == 0 {
 		// maybe put something more ... non screaming
 		errMsg := "CRITICAL ERROR IN COMMIT CHECKER! Please make contact the team that made the checker. #1"
 		issue := ModuleIssue{Message: errMsg, Critical: true}
@@ -182,52 +406,75 @@ func (cc *CommitChecker) Run() {
 		return
 	}
 
-	lines = lines[0 : len(lines)-1]
-	cc.commits = make([]string, len(lines))
-
-	for i, line := range lines {
-		splitLine := strings.SplitN(line, " ", 2)
+	policy := utils.Config.CommitChecker.Policy
+	if policy == nil {
+		policy = &defaultCommitPolicy
+	}
 
-		// this shouldn't happen but ... you never know
-		if len(splitLine) == 0 {
-			errMsg := "CRITICAL ERROR IN COMMIT CHECKER! Please make contact the team that made the checker. #2"
-			issue := ModuleIssue{Message: errMsg, Critical: true}
-			cc.Issues = append(cc.Issues, issue)
-			return
-		}
+	for i, commit := range commits {
 		if utils.Config.CommitChecker.UseFormat {
-			err := checkCommits(splitLine[1])
-			if err != nil {
-				errMsg := "Bad commit detected: " + err.Error() + " the commit was \"" + splitLine[1] + "\"\n"
-				issue := ModuleIssue{Message: errMsg}
+			violations := checkCommit(commit.subject, policy)
+			for _, violation := range violations {
+				errMsg := fmt.Sprintf("Bad commit %s [%s]: %s, the commit was \"%s\"\n",
+					commit.shortHash, violation.rule, violation.message, commit.subject)
+				issue := ModuleIssue{Message: errMsg, RuleID: "commit-" + violation.rule, Severity: "warning"}
 				cc.Issues = append(cc.Issues, issue)
-				continue
+			}
+			if len(violations) > 0 {
+				commits[i].flagged = true
+				commits[i].penalized = true
 			}
 		}
+	}
 
-		cc.commits[i] = splitLine[1]
+	historyIssues, historyPenalties := checkHistory(commits, utils.Config.CommitChecker.History)
+	cc.Issues = append(cc.Issues, historyIssues...)
+
+	cc.commits = commits
+
+	// The late penalty is applied on the total score, not on this module
+	if utils.Config.Deadline != "" {
+		cc.checkDeadline()
 	}
 
 	minCommits := utils.Config.CommitChecker.MinCommits
 	cc.score = 100
 
+	// Deductions are made once per bad commit, no matter how many rules it broke,
+	// and once per heuristic about the whole history
+	penalties := historyPenalties
+	for _, commit := range cc.commits {
+		if commit.penalized {
+			penalties++
+		}
+	}
+
 	if minCommits > len(cc.commits) {
 		pointsToDeduct := 1
 		cc.score -= pointsToDeduct
 		issueMsg := "Not enough commits have been made."
-		cc.Issues = append(cc.Issues, ModuleIssue{Message: issueMsg})
+		cc.Issues = append(cc.Issues, ModuleIssue{Message: issueMsg, RuleID: "min-commits", Severity: "warning"})
+		penalties++
 	}
 
 	deduction := 100 / utils.Config.CommitChecker.MinCommits
 
-	if len(cc.Issues) == 0 {
-		return
-	}
-
-	if len(cc.Issues) > 3 {
+	if penalties > 3 {
 		cc.score = 0
-		return
+	} else {
+		cc.score -= penalties * deduction
 	}
 
-	cc.score -= len(cc.Issues) * deduction
+	if hygiene := utils.Config.CommitChecker.Hygiene; hygiene != nil {
+		var hygieneIssues []ModuleIssue
+		hygieneIssues, cc.gitignore = checkHygiene(hygiene)
+		cc.Issues = append(cc.Issues, hygieneIssues...)
+
+		hygieneDeduction := len(hygieneIssues) * hygiene.Deduction
+		if hygiene.MaxDeduction > 0 {
+			hygieneDeduction = min(hygieneDeduction, hygiene.MaxDeduction)
+		}
+
+		cc.score = max(cc.score-hygieneDeduction, 0)
+	}
 }
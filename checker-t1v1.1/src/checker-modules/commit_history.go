@@ -0,0 +1,271 @@
+package checkermodules
+
+import (
+	"checker-pa/src/utils"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	recordSeparator = "\x1e"
+	fieldSeparator  = "\x1f"
+)
+
+// Every commit starts with a record separator followed by its fields,
+// the numstat lines come right after
+var gitLogFormat = "--format=" + recordSeparator + strings.Join([]string{"%H", "%h", "%an", "%ae", "%aI", "%cI", "%s"}, fieldSeparator)
+
+type commitInfo struct {
+	hash      string
+	shortHash string
+	author    string
+	email     string
+	date      time.Time
+	committed time.Time
+	subject   string
+	added     int
+	deleted   int
+	paths     []string
+	flagged   bool // highlighted in the history
+	penalized bool // broke a format rule or holds most of the work, penalized once
+}
+
+func (ci *commitInfo) changedLines() int {
+	return ci.added + ci.deleted
+}
+
+// Parses the output of git log ran with gitLogFormat and --numstat
+func parseGitLog(output string) ([]commitInfo, error) {
+	var commits []commitInfo
+
+	for _, record := range strings.Split(output, recordSeparator) {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+
+		lines := strings.Split(strings.TrimRight(record, "\n"), "\n")
+
+		fields := strings.Split(lines[0], fieldSeparator)
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("unexpected git log line: %q", lines[0])
+		}
+
+		date, err := time.Parse(time.RFC3339, fields[4])
+		if err != nil {
+			return nil, err
+		}
+
+		committed, err := time.Parse(time.RFC3339, fields[5])
+		if err != nil {
+			return nil, err
+		}
+
+		commit := commitInfo{
+			hash:      fields[0],
+			shortHash: fields[1],
+			author:    fields[2],
+			email:     fields[3],
+			date:      date,
+			committed: committed,
+			subject:   fields[6],
+		}
+
+		for _, line := range lines[1:] {
+			stat := strings.SplitN(line, "\t", 3)
+			if len(stat) != 3 {
+				continue
+			}
+
+			// Binary files show "-" instead of the line counts
+			added, _ := strconv.Atoi(stat[0])
+			deleted, _ := strconv.Atoi(stat[1])
+
+			commit.added += added
+			commit.deleted += deleted
+			commit.paths = append(commit.paths, stat[2])
+		}
+
+		commits = append(commits, commit)
+	}
+
+	return commits, nil
+}
+
+// Applies the history heuristics, flagging the offending commits.
+// Returns the issues found and the penalties of the heuristics about the whole history,
+// the unexpected authors and the rush, each counting once however many commits they concern.
+// The giant commits are marked as penalized instead, with the commits breaking a format rule
+func checkHistory(commits []commitInfo, history *utils.CommitHistory) ([]ModuleIssue, int) {
+	if history == nil || len(commits) == 0 {
+		return nil, 0
+	}
+
+	var issues []ModuleIssue
+	penalties := 0
+
+	totalLines := 0
+	for _, commit := range commits {
+		totalLines += commit.changedLines()
+	}
+
+	// Single commit holding most of the work
+	if history.MaxCommitShare > 0 && len(commits) > 1 && totalLines > 0 {
+		for i := range commits {
+			share := float32(commits[i].changedLines()) / float32(totalLines)
+			if share > history.MaxCommitShare {
+				commits[i].flagged = true
+				commits[i].penalized = true
+				issues = append(issues, ModuleIssue{
+					Message: fmt.Sprintf("Giant commit %s: %d of the %d changed lines (%.0f%%), the limit is %.0f%%",
+						commits[i].shortHash, commits[i].changedLines(), totalLines, share*100, history.MaxCommitShare*100),
+					RuleID:   "commit-giant",
+					Severity: "warning",
+				})
+			}
+		}
+	}
+
+	// Commits made by someone else
+	if len(history.AllowedAuthors) > 0 {
+		strangers := false
+		for i := range commits {
+			if slices.Contains(history.AllowedAuthors, commits[i].author) || slices.Contains(history.AllowedAuthors, commits[i].email) {
+				continue
+			}
+
+			commits[i].flagged = true
+			strangers = true
+			issues = append(issues, ModuleIssue{
+				Message: fmt.Sprintf("Unexpected author for commit %s: %s <%s>",
+					commits[i].shortHash, commits[i].author, commits[i].email),
+				RuleID:   "commit-author",
+				Severity: "warning",
+			})
+		}
+		if strangers {
+			penalties++
+		}
+	}
+
+	// Whole history made in a rush
+	if history.MinHistoryMinutes > 0 && len(commits) > 1 {
+		first, last := commits[0].date, commits[0].date
+		for _, commit := range commits {
+			if commit.date.Before(first) {
+				first = commit.date
+			}
+			if commit.date.After(last) {
+				last = commit.date
+			}
+		}
+
+		span := last.Sub(first)
+		if span < time.Duration(history.MinHistoryMinutes)*time.Minute {
+			issues = append(issues, ModuleIssue{
+				Message: fmt.Sprintf("All the %d commits were made in %s, the history should span at least %d minutes",
+					len(commits), span.Round(time.Second), history.MinHistoryMinutes),
+				RuleID:   "commit-rushed",
+				Severity: "warning",
+			})
+			penalties++
+		}
+	}
+
+	return issues, penalties
+}
+
+func (ci *commitInfo) touchesSources() bool {
+	for _, path := range ci.paths {
+		if isSourceFile(path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the last commits touching the sources before and after the deadline.
+// Commits are compared by the commit date, which is harder to fake than the author date
+func lastSourceCommits(commits []commitInfo, deadline time.Time) (before *commitInfo, after *commitInfo) {
+	for i := range commits {
+		if !commits[i].touchesSources() {
+			continue
+		}
+
+		if commits[i].committed.After(deadline) {
+			if after == nil || commits[i].committed.After(after.committed) {
+				after = &commits[i]
+			}
+		} else if before == nil || commits[i].committed.After(before.committed) {
+			before = &commits[i]
+		}
+	}
+
+	return before, after
+}
+
+func commitTableString(commits []commitInfo) string {
+	str := strings.Builder{}
+
+	str.WriteString(fmt.Sprintf("%-9s %-20s %-16s %6s %6s %5s  %s\n", "HASH", "AUTHOR", "DATE", "+", "-", "FILES", "SUBJECT"))
+
+	for _, commit := range commits {
+		marker := ""
+		if commit.flagged {
+			marker = " (!)"
+		}
+
+		str.WriteString(fmt.Sprintf("%-9s %-20.20s %-16s %6d %6d %5d  %s%s\n",
+			commit.shortHash, commit.author, commit.date.Format("2006-01-02 15:04"),
+			commit.added, commit.deleted, len(commit.paths), commit.subject, marker))
+	}
+
+	return str.String()
+}
+
+func newCommitTable(commits []commitInfo) *tview.Table {
+	table := tview.NewTable()
+	table.SetBorders(false)
+	table.SetFixed(1, 0)
+	table.SetSelectable(true, false)
+
+	for col, header := range []string{"Hash", "Author", "Date", "+", "-", "Files", "Subject"} {
+		table.SetCell(0, col, tview.NewTableCell(header).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false))
+	}
+
+	for i, commit := range commits {
+		row := []string{
+			commit.shortHash,
+			commit.author,
+			commit.date.Format("2006-01-02 15:04"),
+			strconv.Itoa(commit.added),
+			strconv.Itoa(commit.deleted),
+			strconv.Itoa(len(commit.paths)),
+			commit.subject,
+		}
+
+		textColor := tcell.ColorWhite
+		if commit.flagged {
+			textColor = tcell.ColorRed
+		}
+
+		for col, text := range row {
+			cell := tview.NewTableCell(text).SetTextColor(textColor)
+			// Let the subject take the remaining space
+			if col == len(row)-1 {
+				cell.SetExpansion(1)
+			}
+			table.SetCell(i+1, col, cell)
+		}
+	}
+
+	return table
+}
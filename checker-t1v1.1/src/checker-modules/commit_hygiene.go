@@ -0,0 +1,265 @@
+package checkermodules
+
+import (
+	"bytes"
+	"checker-pa/src/utils"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	checkerLogName = "checker_log.txt"
+)
+
+// Build artifacts that should never be committed
+var artifactExtensions = []string{".o", ".obj", ".a", ".so", ".dll", ".exe", ".dylib", ".gch"}
+
+// Magic numbers of ELF and Mach-O executables, PE ones are checked by peHeader
+var executableMagics = [][]byte{
+	[]byte("\x7fELF"),
+	{0xcf, 0xfa, 0xed, 0xfe},
+	{0xce, 0xfa, 0xed, 0xfe},
+}
+
+type trackedFile struct {
+	path     string // relative to the current directory, to open the file
+	repoPath string // relative to the repository root, for the .gitignore patterns
+}
+
+// Returns the absolute path of the root of the repository holding the source path
+func repoRoot() (string, error) {
+	output, err := gitCommand("rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Clean(strings.TrimSpace(string(output))), nil
+}
+
+// Lists the files tracked by git inside the source path
+func listTrackedFiles() ([]trackedFile, error) {
+	output, err := gitCommand("ls-files", "--full-name", "-z").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := repoRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []trackedFile
+
+	for _, repoPath := range strings.Split(string(output), "\x00") {
+		if repoPath == "" {
+			continue
+		}
+
+		path := filepath.Join(root, filepath.FromSlash(repoPath))
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			path = rel
+		}
+
+		files = append(files, trackedFile{path: path, repoPath: repoPath})
+	}
+
+	return files, nil
+}
+
+// Returns the .gitignore pattern of the directory, anchored at the repository root
+func dirPattern(root string, dir string) (string, bool) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(root, absDir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+
+	return "/" + filepath.ToSlash(rel) + "/", true
+}
+
+// Looks for a .gitignore in the source path and its parents, up to the repository root
+func findGitignore() bool {
+	root, err := repoRoot()
+	if err != nil {
+		return false
+	}
+
+	dir, err := filepath.Abs(utils.Config.SourcePath)
+	if err != nil {
+		return false
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".gitignore")); err == nil {
+			return true
+		}
+
+		parent := filepath.Dir(dir)
+		if dir == root || parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// Returns whether the file starts with the "MZ" stub pointing to a "PE\0\0" signature,
+// the two bytes alone are found at the start of text files too
+func peHeader(f *os.File, header []byte) bool {
+	const lfanewOffset = 0x3c
+
+	if len(header) < lfanewOffset+4 || !bytes.HasPrefix(header, []byte("MZ")) {
+		return false
+	}
+
+	lfanew := binary.LittleEndian.Uint32(header[lfanewOffset:])
+	if lfanew < lfanewOffset+4 || lfanew > 1<<20 {
+		return false
+	}
+
+	signature := make([]byte, 4)
+	n, _ := f.ReadAt(signature, int64(lfanew))
+
+	return n == 4 && string(signature) == "PE\x00\x00"
+}
+
+// Returns whether the file is an ELF, Mach-O or PE executable, by its content only:
+// the executable bit is set on the committed scripts too
+func isExecutable(file trackedFile) bool {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	header := make([]byte, 64)
+	n, _ := io.ReadFull(f, header)
+	header = header[:n]
+
+	for _, magic := range executableMagics {
+		if bytes.HasPrefix(header, magic) {
+			return true
+		}
+	}
+
+	return peHeader(f, header)
+}
+
+// Returns whether the path is inside the given directory
+func isInside(path string, dir string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
+
+// Inspects the tracked files for binaries, build artifacts and checker output.
+// Returns the issues found and a suggested .gitignore for the root of the repository
+func checkHygiene(hygiene *utils.RepoHygiene) ([]ModuleIssue, string) {
+	files, err := listTrackedFiles()
+	if err != nil {
+		utils.Err("failed listing the tracked files: " + err.Error())
+		return nil, ""
+	}
+
+	root, err := repoRoot()
+	if err != nil {
+		utils.Err("failed finding the repository root: " + err.Error())
+		return nil, ""
+	}
+
+	var issues []ModuleIssue
+	var ignored []string
+
+	addIssue := func(path string, reason string, pattern string) {
+		issues = append(issues, ModuleIssue{
+			File:     path,
+			Message:  fmt.Sprintf("Committed %s: %s", reason, path),
+			RuleID:   "repo-hygiene",
+			Severity: "warning",
+		})
+		if !slices.Contains(ignored, pattern) {
+			ignored = append(ignored, pattern)
+		}
+	}
+
+	hasGitignore := findGitignore()
+
+	outputDirs := []string{utils.Config.OutputPath, utils.Config.ForwardPath, utils.Config.TempPath}
+
+	for _, file := range files {
+		if filepath.Base(file.path) == ".gitignore" {
+			continue
+		}
+
+		if dir := slices.IndexFunc(outputDirs, func(dir string) bool { return dir != "" && isInside(file.path, dir) }); dir != -1 {
+			pattern, ok := dirPattern(root, outputDirs[dir])
+			if !ok {
+				pattern = "/" + file.repoPath
+			}
+			addIssue(file.path, "checker output", pattern)
+			continue
+		}
+
+		if filepath.Base(file.path) == checkerLogName {
+			addIssue(file.path, "checker log", checkerLogName)
+			continue
+		}
+
+		if ext := filepath.Ext(file.path); slices.Contains(artifactExtensions, ext) {
+			addIssue(file.path, "build artifact", "*"+ext)
+			continue
+		}
+
+		if isExecutable(file) {
+			addIssue(file.path, "executable", "/"+file.repoPath)
+			continue
+		}
+
+		// The tests are usually provided with the assignment
+		if isInside(file.path, utils.Config.InputPath) || isInside(file.path, utils.Config.RefPath) {
+			continue
+		}
+
+		if hygiene.MaxFileSize > 0 {
+			if stat, err := os.Stat(file.path); err == nil && stat.Size() > int64(hygiene.MaxFileSize)*1024 {
+				addIssue(file.path, fmt.Sprintf("large file (%d KB)", stat.Size()/1024), "/"+file.repoPath)
+			}
+		}
+	}
+
+	if !hasGitignore {
+		issues = append(issues, ModuleIssue{
+			Message:  "The repository has no .gitignore",
+			RuleID:   "repo-hygiene",
+			Severity: "warning",
+		})
+	}
+
+	if len(ignored) == 0 {
+		return issues, ""
+	}
+
+	return issues, strings.Join(ignored, "\n")
+}
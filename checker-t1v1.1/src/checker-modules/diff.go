@@ -9,6 +9,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/fatih/color"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
@@ -30,17 +31,57 @@ type FormattedOutput struct {
 
 // Store differences for each file
 type FileCompareResult struct {
-	filename string
-	matched  bool
-	diffs    []diffmatchpatch.Diff
-	points   int
+	filename   string
+	outputPath string
+	matched    bool
+	skipped    bool   // left out by the test filter
+	limit      string // hit by the run, e.g. TLE
+	diffs      []diffmatchpatch.Diff
+	points     int
 	FormattedOutput
 }
+
+// Returns the name of the test, with the limit its run hit when it failed
+func (fcr *FileCompareResult) title() string {
+	if fcr.limit != "" && !fcr.matched {
+		return fmt.Sprintf("%s %s", fcr.filename, fcr.limit)
+	}
+
+	return fcr.filename
+}
+
+// Returns the output line where the first difference occurs
+func (fcr *FileCompareResult) firstDiffLine() int {
+	if len(fcr.diffs) > 0 && fcr.diffs[0].Type == diffmatchpatch.DiffEqual {
+		return strings.Count(fcr.diffs[0].Text, "\n") + 1
+	}
+
+	return 1
+}
+
+func (fcr *FileCompareResult) chunks() []DiffChunk {
+	var chunks []DiffChunk
+
+	for _, diff := range fcr.diffs {
+		op := "equal"
+		switch diff.Type {
+		case diffmatchpatch.DiffInsert:
+			op = "insert"
+		case diffmatchpatch.DiffDelete:
+			op = "delete"
+		}
+		chunks = append(chunks, DiffChunk{Op: op, Text: diff.Text})
+	}
+
+	return chunks
+}
+
 type DiffModule struct {
 	ModuleOutput
 	totalScore int
 	uniqueName string
 	results    []FileCompareResult
+	groups     []groupResult
 	matchCount int
 	totalFiles int
 	status     ModuleStatus
@@ -92,12 +133,16 @@ func (dm *DiffModule) Run() {
 	defer func() { dm.status = Ready }()
 
 	config := utils.Config.UserConfig
-	folder1 := config.RefPath
-	folder2 := config.OutputPath
+	outputFolder := config.OutputPath
 
-	numFiles := len(utils.Config.Tests)
+	numFiles := 0
+	for _, test := range utils.Config.Tests {
+		if utils.TestSelected(test) {
+			numFiles++
+		}
+	}
 
-	matchedCount := dm.compareFilesInFolders(folder1, folder2)
+	matchedCount := dm.compareFilesInFolders(outputFolder)
 	/*
 		if err != nil {
 			dm.Issues = append(dm.Issues, ModuleIssue{
@@ -113,12 +158,30 @@ func (dm *DiffModule) Run() {
 	// Calculate score based on matched files
 	// dm.totalScore = int((float64(matchedCount) / float64(numFiles)) * 100)
 
+	// The tests of a group only count through the group score
+	dm.scoreGroups()
+	for _, group := range dm.groups {
+		dm.totalScore += group.score
+	}
+
 	// Add issues for mismatched files
-	for _, result := range dm.results {
-		dm.totalScore += result.points
-		if !result.matched {
+	for i, result := range dm.results {
+		if utils.GroupOf(utils.Config.Tests[i]) < 0 {
+			dm.totalScore += result.points
+		}
+		if !result.matched && !result.skipped {
+			message := fmt.Sprintf("File %s has differences", result.filename)
+			if result.limit != "" {
+				message = fmt.Sprintf("%s: %s", result.filename, utils.LimitMessage(result.limit))
+			}
+
 			dm.Issues = append(dm.Issues, ModuleIssue{
-				Message: fmt.Sprintf("File %s has differences", result.filename),
+				File:     result.outputPath,
+				Line:     result.firstDiffLine(),
+				Col:      1,
+				Message:  message,
+				RuleID:   "output-mismatch",
+				Severity: "error",
 			})
 		}
 	}
@@ -145,23 +208,14 @@ func (dm *DiffModule) Display(d *display.Display) {
 
 	fileTable := tview.NewTable()
 
-	fileTable.SetInputCapture(utils.TableSelector(len(dm.results), fileTable))
-
-	currentRow := 0
-	currentCol := 0
-
-	cMaxRow, cMaxCol := utils.ComputeBestArea(len(dm.results))
-
-	for _, result := range dm.results {
-		// utils.Log(result.filename)
-		if currentRow >= cMaxRow && currentCol < cMaxCol {
-			currentRow = 0
-			currentCol++
-		}
-
-		cell := tview.NewTableCell(fmt.Sprintf("[%02d] %s", result.points, result.filename))
+	resultCell := func(index int) *tview.TableCell {
+		result := dm.results[index]
+		cell := tview.NewTableCell(fmt.Sprintf("[%02d] %s", result.points, result.title()))
 
-		if result.matched {
+		if result.skipped {
+			cell.SetText(fmt.Sprintf("[--] %s", result.filename))
+			cell.SetTextColor(tcell.ColorGray)
+		} else if result.matched {
 			cell.SetTextColor(tcell.ColorGreen)
 		} else {
 			cell.SetTextColor(tcell.ColorRed)
@@ -179,6 +233,26 @@ func (dm *DiffModule) Display(d *display.Display) {
 
 			return false
 		})
+
+		return cell
+	}
+
+	// Clustered by group, with the group totals as headers
+	cells := dm.groupedCells(d, resultCell)
+
+	fileTable.SetInputCapture(utils.TableSelector(len(cells), fileTable))
+
+	currentRow := 0
+	currentCol := 0
+
+	cMaxRow, cMaxCol := utils.ComputeBestArea(len(cells))
+
+	for _, cell := range cells {
+		if currentRow >= cMaxRow && currentCol < cMaxCol {
+			currentRow = 0
+			currentCol++
+		}
+
 		fileTable.SetCell(currentRow, currentCol, cell)
 
 		currentRow++
@@ -204,12 +278,27 @@ func (dm *DiffModule) Dump() {
 		return
 	}
 
-	if len(dm.Issues) > 0 {
-		fmt.Println(dm.ModuleError.String())
-	} else {
+	dm.dumpGroups()
+
+	if len(dm.Issues) == 0 {
 		fmt.Println("All tests passed!")
+		fmt.Println()
+		return
+	}
+
+	view := diffView()
+
+	for _, result := range dm.results {
+		if result.matched || result.skipped || result.filename == "" {
+			continue
+		}
+
+		if result.limit != "" {
+			fmt.Println(color.RedString("%s: %s", result.filename, utils.LimitMessage(result.limit)))
+		}
+		fmt.Println(color.RedString("File %s has differences", result.filename))
+		fmt.Println(result.lineDiffString(view))
 	}
-	fmt.Println()
 
 }
 
@@ -238,6 +327,9 @@ func updateComparisonDisplay(d *display.Display, result FileCompareResult) {
 	// Prepare the output section content
 	var outContent strings.Builder
 	outContent.WriteString("[::b]Output content for " + result.filename + ":[white]\n")
+	if result.limit != "" {
+		outContent.WriteString("[red]" + utils.LimitMessage(result.limit) + "[white]\n")
+	}
 	outContent.WriteString("------------------------------\n\n")
 
 	for _, line := range result.output {
@@ -305,11 +397,92 @@ func (dm *DiffModule) Reset() {
 	dm.totalScore = 0
 	dm.Issues = nil
 	dm.results = nil
+	dm.groups = nil
 	dm.matchCount = 0
 	dm.totalFiles = 0
 	dm.status = Queued
 }
 
+func (dm *DiffModule) ShowTest(index int) (string, bool) {
+	if index >= len(dm.results) || dm.results[index].filename == "" {
+		return "", false
+	}
+
+	result := dm.results[index]
+	if result.skipped {
+		return fmt.Sprintf("%s: skipped", result.filename), true
+	}
+	if result.matched {
+		return color.GreenString("%s: files are identical", result.filename), true
+	}
+	if result.limit != "" {
+		return color.RedString("%s: %s", result.filename, utils.LimitMessage(result.limit)) + "\n\n" + result.lineDiffString(diffView()), true
+	}
+
+	return color.RedString("%s: files are different", result.filename) + "\n\n" + result.lineDiffString(diffView()), true
+}
+
+// Lines of the diff shown in the reports
+const excerptLines = 12
+
+func (dm *DiffModule) Report() ModuleReport {
+	report := newModuleReport(dm, dm.totalScore)
+
+	// No verdicts when the module didn't run
+	if dm.status != Ready {
+		return report
+	}
+
+	for i, test := range utils.Config.Tests {
+		verdict := VerdictMissing
+		points := 0
+
+		// Results are left empty when the files couldn't be read
+		if i < len(dm.results) && dm.results[i].filename != "" {
+			verdict = VerdictFailed
+			if dm.results[i].skipped {
+				verdict = VerdictSkipped
+			} else if dm.results[i].matched {
+				verdict = VerdictPassed
+			}
+			points = dm.results[i].points
+		}
+
+		testReport := newTestReport(test, verdict, points)
+		if group := utils.GroupOf(test); group >= 0 {
+			testReport.Group = utils.Config.Groups[group].Name
+		}
+		if verdict == VerdictFailed {
+			testReport.Details = dm.results[i].lineDiffString(utils.DiffView{Context: 1, MaxLines: excerptLines})
+			testReport.Diff = dm.results[i].chunks()
+
+			if limit := dm.results[i].limit; limit != "" {
+				testReport.Details = strings.TrimSpace(utils.LimitMessage(limit) + "\n" + testReport.Details)
+			}
+		}
+
+		report.Tests = append(report.Tests, testReport)
+	}
+
+	for _, gr := range dm.groups {
+		groupReport := GroupReport{
+			Name:     gr.group.Name,
+			Policy:   gr.policy(),
+			Score:    gr.score,
+			MaxScore: gr.maxScore,
+			Passed:   gr.passed,
+			Total:    gr.selected,
+		}
+		for _, index := range gr.indexes {
+			groupReport.Tests = append(groupReport.Tests, utils.Config.Tests[index].File)
+		}
+
+		report.Groups = append(report.Groups, groupReport)
+	}
+
+	return report
+}
+
 func (dm *DiffModule) Score() int {
 	return int(float32(dm.totalScore) * utils.Config.RefChecker.Grade)
 }
@@ -366,7 +539,8 @@ func (ar *asyncResults) inc() {
 
 // TODO: resolve absolute path for the folders
 
-func (dm *DiffModule) compareFilesInFolders(folder1, folder2 string) int {
+// Compares the outputs in the folder with the references of the tests
+func (dm *DiffModule) compareFilesInFolders(outputFolder string) int {
 	wg := sync.WaitGroup{}
 
 	ar := asyncResults{}
@@ -378,8 +552,13 @@ func (dm *DiffModule) compareFilesInFolders(folder1, folder2 string) int {
 		go func() {
 			defer wg.Done()
 
-			file1 := fmt.Sprintf("%s/%s.ref", folder1, test.File)
-			file2 := fmt.Sprintf("%s/%s.out", folder2, test.File)
+			if !utils.TestSelected(test) {
+				ar.add(i, FileCompareResult{filename: test.DisplayName, skipped: true})
+				return
+			}
+
+			file1 := test.RefFile()
+			file2 := fmt.Sprintf("%s/%s.out", outputFolder, test.File)
 
 			// utils.Log(file1)
 			// utils.Log(file2)
@@ -392,9 +571,16 @@ func (dm *DiffModule) compareFilesInFolders(folder1, folder2 string) int {
 				return // 0, fmt.Errorf("error reading file1: %w", err)
 			}
 
+			limit := utils.LimitOf(test.File)
+
 			text2, err := readFile(file2)
 			if err != nil {
 				utils.Err(fmt.Sprintf("failed reading file: %s", file2))
+
+				// Killed before writing its output, the limit is the verdict
+				if limit != "" {
+					ar.add(i, FileCompareResult{filename: test.DisplayName, outputPath: file2, limit: limit})
+				}
 				return // 0, fmt.Errorf("error reading file2: %w", err)
 			}
 
@@ -412,7 +598,9 @@ func (dm *DiffModule) compareFilesInFolders(folder1, folder2 string) int {
 
 			ar.add(i, FileCompareResult{
 				filename:        test.DisplayName,
+				outputPath:      file2,
 				matched:         matched,
+				limit:           limit,
 				diffs:           diffs,
 				points:          points,
 				FormattedOutput: generateFormattedOutput(diffs),
@@ -428,45 +616,3 @@ func (dm *DiffModule) compareFilesInFolders(folder1, folder2 string) int {
 
 	return ar.matches
 }
-
-/*
-func showDifferences(result FileCompareResult, displayType int) {
-	if result.matched {
-		fmt.Printf("\033[32mFile %s: Files are identical\033[0m\n", result.filename)
-		return
-	}
-
-	fmt.Printf("\033[31mFile %s: Files are different\033[0m\n", result.filename)
-
-	if displayType == 1 {
-		// Original inline display
-		for _, diff := range result.diffs {
-			switch diff.Type {
-			case diffmatchpatch.DiffInsert:
-				fmt.Printf("\033[32m%s\033[0m", diff.Text)
-			case diffmatchpatch.DiffDelete:
-				fmt.Printf("\033[31m%s\033[0m", diff.Text)
-			case diffmatchpatch.DiffEqual:
-				fmt.Printf("%s", diff.Text)
-			}
-		}
-	} else if displayType == 2 {
-		fmt.Println("\nReference:")
-		fmt.Println("----------")
-		for _, line := range result.reference {
-			if line != "" {
-				fmt.Println(line)
-			}
-		}
-
-		fmt.Println("\nOutput:")
-		fmt.Println("-------")
-		for _, line := range result.output {
-			if line != "" {
-				fmt.Println(line)
-			}
-		}
-	}
-	fmt.Println()
-}
-*/
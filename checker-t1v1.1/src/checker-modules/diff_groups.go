@@ -0,0 +1,173 @@
+package checkermodules
+
+import (
+	"checker-pa/src/display"
+	"checker-pa/src/utils"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Score of a test group, computed from the results of its tests
+type groupResult struct {
+	group    utils.TestGroup
+	indexes  []int // of the tests and their results
+	passed   int
+	selected int
+	score    int
+	maxScore int
+}
+
+func (gr *groupResult) policy() string {
+	if gr.group.Policy == "" {
+		return utils.PolicySum
+	}
+
+	return gr.group.Policy
+}
+
+// Skipped when the test filter leaves out every test of the group
+func (gr *groupResult) skipped() bool {
+	return gr.selected == 0
+}
+
+func (gr *groupResult) String() string {
+	return fmt.Sprintf("%s (%s) %d / %d, %d / %d tests passed", gr.group.Name, gr.policy(), gr.score, gr.maxScore, gr.passed, gr.selected)
+}
+
+// Computes the group scores from the test results, the tests of a group don't count on their own
+func (dm *DiffModule) scoreGroups() {
+	dm.groups = nil
+
+	for i, group := range utils.Config.Groups {
+		gr := groupResult{group: group, maxScore: group.MaxScore()}
+
+		weight, passedWeight := 0, 0
+
+		for j, test := range utils.Config.Tests {
+			if utils.GroupOf(test) != i {
+				continue
+			}
+			gr.indexes = append(gr.indexes, j)
+
+			if j >= len(dm.results) || dm.results[j].skipped {
+				continue
+			}
+			gr.selected++
+			weight += test.Score
+
+			// Missing outputs count as failed
+			if dm.results[j].matched {
+				gr.passed++
+				passedWeight += test.Score
+			}
+		}
+
+		switch {
+		case gr.skipped():
+		case gr.policy() == utils.PolicyAllOrNothing:
+			if gr.passed == gr.selected {
+				gr.score = gr.maxScore
+			}
+		case weight > 0:
+			gr.score = gr.maxScore * passedWeight / weight
+		default:
+			// Tests without a score share the group score evenly
+			gr.score = gr.maxScore * gr.passed / gr.selected
+		}
+
+		dm.groups = append(dm.groups, gr)
+	}
+}
+
+// Returns the cell of the group header, showing the group total
+func groupCell(d *display.Display, gr groupResult) *tview.TableCell {
+	cell := tview.NewTableCell(fmt.Sprintf("[%02d/%02d] %s", gr.score, gr.maxScore, gr.group.Name))
+	cell.SetAttributes(tcell.AttrBold)
+
+	switch {
+	case gr.skipped():
+		cell.SetTextColor(tcell.ColorGray)
+	case gr.score == gr.maxScore:
+		cell.SetTextColor(tcell.ColorGreen)
+	case gr.score > 0:
+		cell.SetTextColor(tcell.ColorYellow)
+	default:
+		cell.SetTextColor(tcell.ColorRed)
+	}
+
+	cell.SetSelectable(true)
+	cell.SetClickedFunc(func() bool {
+		d.NewPage(gr.group.Name, true)
+		d.CurrentContainer().SetDirection(tview.FlexColumn)
+		d.CurrentContainer().SyncSections(true)
+		d.AddWritableContainer(d.CurrentContainer(), 0, 1)
+
+		d.PrintPage(0, "$nb", "")
+		d.Println(gr.String())
+
+		d.App.SetFocus(d.CurrentContainer().Container)
+		d.CurrentContainer().WrapInput(d.CurrentContainer().Sections[0])
+
+		return false
+	})
+
+	return cell
+}
+
+// Orders the table cells by group, each group under its header and the other tests last
+func (dm *DiffModule) groupedCells(d *display.Display, resultCell func(index int) *tview.TableCell) []*tview.TableCell {
+	var cells []*tview.TableCell
+
+	for _, gr := range dm.groups {
+		cells = append(cells, groupCell(d, gr))
+		for _, index := range gr.indexes {
+			if index < len(dm.results) {
+				cells = append(cells, resultCell(index))
+			}
+		}
+	}
+
+	var others []*tview.TableCell
+	for i, test := range utils.Config.Tests {
+		if i < len(dm.results) && utils.GroupOf(test) < 0 {
+			others = append(others, resultCell(i))
+		}
+	}
+
+	if len(others) > 0 && len(dm.groups) > 0 {
+		header := tview.NewTableCell("Other tests").SetAttributes(tcell.AttrBold)
+		header.SetSelectable(true)
+		cells = append(cells, header)
+	}
+
+	return append(cells, others...)
+}
+
+// Prints the group totals
+func (dm *DiffModule) dumpGroups() {
+	if len(dm.groups) == 0 {
+		return
+	}
+
+	fmt.Println("Groups:")
+	for _, gr := range dm.groups {
+		line := "  " + gr.String()
+
+		switch {
+		case gr.skipped():
+			line = "  " + gr.group.Name + ": skipped"
+		case gr.score == gr.maxScore:
+			line = color.GreenString(line)
+		case gr.score > 0:
+			line = color.YellowString(line)
+		default:
+			line = color.RedString(line)
+		}
+
+		fmt.Println(line)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,304 @@
+package checkermodules
+
+import (
+	"checker-pa/src/utils"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+const (
+	tabMarker       = "→"
+	spaceMarker     = "·"
+	crMarker        = "␍"
+	noNewlineMarker = `\ No newline at end of file`
+	noEOLMarker     = "[noeol]"
+)
+
+var defaultDiffView = utils.DiffView{
+	Style:    "unified",
+	Context:  3,
+	Color:    true,
+	MaxLines: 200,
+	Width:    120,
+}
+
+type lineOp struct {
+	op   diffmatchpatch.Operation
+	text string // including the line terminator, if any
+	// Line numbers in the reference and the output, starting at 1
+	refLine int
+	outLine int
+}
+
+// Splits the text keeping the terminators, so the missing final newline stays visible
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// Maps every distinct line to a rune, so the lines can be diffed as characters.
+// The line mode of diffmatchpatch mixes up the line indexes past the first ten lines
+type lineEncoder struct {
+	runes map[string]rune
+	lines []string
+}
+
+func (le *lineEncoder) encode(text string) []rune {
+	var encoded []rune
+
+	for _, line := range splitLines(text) {
+		r, ok := le.runes[line]
+		if !ok {
+			r = rune(len(le.lines))
+			// Skip the surrogate halves, they aren't valid runes
+			if r >= 0xD800 {
+				r += 0x800
+			}
+			le.runes[line] = r
+			le.lines = append(le.lines, line)
+		}
+		encoded = append(encoded, r)
+	}
+
+	return encoded
+}
+
+func (le *lineEncoder) decode(r rune) string {
+	if r >= 0xD800 {
+		r -= 0x800
+	}
+
+	return le.lines[r]
+}
+
+// Diffs the reference and the output line by line
+func lineDiff(ref string, out string) []lineOp {
+	encoder := lineEncoder{runes: make(map[string]rune)}
+	refRunes := encoder.encode(ref)
+	outRunes := encoder.encode(out)
+
+	diffs := diffmatchpatch.New().DiffMainRunes(refRunes, outRunes, false)
+
+	var ops []lineOp
+	refLine, outLine := 1, 1
+
+	for _, diff := range diffs {
+		for _, r := range diff.Text {
+			line := encoder.decode(r)
+			ops = append(ops, lineOp{op: diff.Type, text: line, refLine: refLine, outLine: outLine})
+
+			if diff.Type != diffmatchpatch.DiffInsert {
+				refLine++
+			}
+			if diff.Type != diffmatchpatch.DiffDelete {
+				outLine++
+			}
+		}
+	}
+
+	return ops
+}
+
+// Makes the invisible characters visible: tabs, trailing spaces and carriage returns
+func showInvisible(line string) string {
+	line = strings.TrimSuffix(line, "\n")
+
+	cr := strings.HasSuffix(line, "\r")
+	line = strings.TrimSuffix(line, "\r")
+
+	trimmed := strings.TrimRight(line, " ")
+	line = trimmed + strings.Repeat(spaceMarker, len(line)-len(trimmed))
+	line = strings.ReplaceAll(line, "\t", tabMarker)
+
+	if cr {
+		line += crMarker
+	}
+
+	return line
+}
+
+type hunk struct {
+	start int // indexes in the ops
+	end   int
+}
+
+// Groups the changes with their context lines, merging the overlapping ones
+func findHunks(ops []lineOp, context int) []hunk {
+	var hunks []hunk
+
+	for i, op := range ops {
+		if op.op == diffmatchpatch.DiffEqual {
+			continue
+		}
+
+		start := max(i-context, 0)
+		end := min(i+context+1, len(ops))
+
+		if len(hunks) > 0 && start <= hunks[len(hunks)-1].end {
+			hunks[len(hunks)-1].end = end
+		} else {
+			hunks = append(hunks, hunk{start: start, end: end})
+		}
+	}
+
+	return hunks
+}
+
+type diffPrinter struct {
+	view  utils.DiffView
+	lines []string
+}
+
+func (dp *diffPrinter) colorize(attr color.Attribute, str string) string {
+	if !dp.view.Color {
+		return str
+	}
+
+	return color.New(attr).Sprint(str)
+}
+
+func (dp *diffPrinter) unified(ops []lineOp, hunks []hunk) {
+	for _, h := range hunks {
+		refCount, outCount := 0, 0
+		for _, op := range ops[h.start:h.end] {
+			if op.op != diffmatchpatch.DiffInsert {
+				refCount++
+			}
+			if op.op != diffmatchpatch.DiffDelete {
+				outCount++
+			}
+		}
+
+		dp.lines = append(dp.lines, dp.colorize(color.FgCyan,
+			fmt.Sprintf("@@ -%d,%d +%d,%d @@", ops[h.start].refLine, refCount, ops[h.start].outLine, outCount)))
+
+		for _, op := range ops[h.start:h.end] {
+			line := showInvisible(op.text)
+
+			switch op.op {
+			case diffmatchpatch.DiffDelete:
+				dp.lines = append(dp.lines, dp.colorize(color.FgRed, "-"+line))
+			case diffmatchpatch.DiffInsert:
+				dp.lines = append(dp.lines, dp.colorize(color.FgGreen, "+"+line))
+			default:
+				dp.lines = append(dp.lines, " "+line)
+			}
+
+			if !strings.HasSuffix(op.text, "\n") {
+				dp.lines = append(dp.lines, noNewlineMarker)
+			}
+		}
+	}
+}
+
+// Pads or cuts the text to the column width
+func fitColumn(text string, width int) string {
+	length := utf8.RuneCountInString(text)
+
+	if length > width {
+		runes := []rune(text)
+		return string(runes[:width-1]) + "…"
+	}
+
+	return text + strings.Repeat(" ", width-length)
+}
+
+func (dp *diffPrinter) sideBySide(ops []lineOp, hunks []hunk) {
+	width := max((dp.view.Width-3)/2, 10)
+
+	// Marks the missing final newline inline, there's no room for a separate line
+	cell := func(op lineOp) string {
+		text := showInvisible(op.text)
+		if !strings.HasSuffix(op.text, "\n") {
+			text += noEOLMarker
+		}
+		return fitColumn(text, width)
+	}
+
+	dp.lines = append(dp.lines, dp.colorize(color.Bold, fitColumn("Reference", width)+"   "+"Output"))
+
+	for _, h := range hunks {
+		dp.lines = append(dp.lines, dp.colorize(color.FgCyan,
+			fmt.Sprintf("@@ line %d / %d @@", ops[h.start].refLine, ops[h.start].outLine)))
+
+		for i := h.start; i < h.end; {
+			if ops[i].op == diffmatchpatch.DiffEqual {
+				dp.lines = append(dp.lines, cell(ops[i])+" | "+cell(ops[i]))
+				i++
+				continue
+			}
+
+			// Pair the deleted lines with the inserted ones that follow
+			var deleted, inserted []lineOp
+			for ; i < h.end && ops[i].op == diffmatchpatch.DiffDelete; i++ {
+				deleted = append(deleted, ops[i])
+			}
+			for ; i < h.end && ops[i].op == diffmatchpatch.DiffInsert; i++ {
+				inserted = append(inserted, ops[i])
+			}
+
+			for j := 0; j < max(len(deleted), len(inserted)); j++ {
+				left, right := strings.Repeat(" ", width), ""
+				separator := " | "
+
+				switch {
+				case j >= len(inserted):
+					left, separator = cell(deleted[j]), " < "
+				case j >= len(deleted):
+					right, separator = cell(inserted[j]), " > "
+				default:
+					left, right = cell(deleted[j]), cell(inserted[j])
+				}
+
+				dp.lines = append(dp.lines,
+					dp.colorize(color.FgRed, left)+dp.colorize(color.FgYellow, separator)+dp.colorize(color.FgGreen, strings.TrimRight(right, " ")))
+			}
+		}
+	}
+}
+
+// Returns the line diff of a failed test, in the style configured for the ref checker
+func (fcr *FileCompareResult) lineDiffString(view utils.DiffView) string {
+	dmp := diffmatchpatch.New()
+	ops := lineDiff(dmp.DiffText1(fcr.diffs), dmp.DiffText2(fcr.diffs))
+	hunks := findHunks(ops, max(view.Context, 0))
+
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	dp := diffPrinter{view: view}
+
+	if view.Style == "side-by-side" {
+		dp.sideBySide(ops, hunks)
+	} else {
+		dp.lines = append(dp.lines,
+			dp.colorize(color.FgRed, "--- "+fcr.filename+" (reference)"),
+			dp.colorize(color.FgGreen, "+++ "+fcr.filename+" (output)"))
+		dp.unified(ops, hunks)
+	}
+
+	if view.MaxLines > 0 && len(dp.lines) > view.MaxLines {
+		hidden := len(dp.lines) - view.MaxLines
+		dp.lines = append(dp.lines[:view.MaxLines], fmt.Sprintf("... %d more lines", hidden))
+	}
+
+	return strings.Join(dp.lines, "\n") + "\n"
+}
+
+// Returns the diff view of the config, or the default one
+func diffView() utils.DiffView {
+	if utils.Config.RefChecker.Diff == nil {
+		return defaultDiffView
+	}
+
+	return *utils.Config.RefChecker.Diff
+}
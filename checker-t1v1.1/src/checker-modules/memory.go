@@ -51,16 +51,51 @@ type Stack struct {
 // Frame represents a simplified single stack frame
 type Frame struct {
 	Fn   string `xml:"fn"`
+	Dir  string `xml:"dir,omitempty"`
 	File string `xml:"file,omitempty"`
 	Line int    `xml:"line,omitempty"`
 	Obj  string `xml:"obj,omitempty"`
 }
 
 type memoryCheckerIssue struct {
+	kind     string
 	message  string
 	function string
+	dir      string
 	file     string
 	line     int
+	stack    []string
+}
+
+// Formats the frames as "fn (file:line)", falling back on the object for library frames
+func (stack *Stack) Trace() []string {
+	var frames []string
+
+	for _, frame := range stack.Frames {
+		location := frame.Obj
+		if frame.File != "" {
+			location = frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		frames = append(frames, fmt.Sprintf("%s (%s)", frame.Fn, location))
+	}
+
+	return frames
+}
+
+func (mci *memoryCheckerIssue) toModuleIssue(severity string) ModuleIssue {
+	file := mci.file
+	if mci.dir != "" && file != "" {
+		file = filepath.Join(mci.dir, file)
+	}
+
+	return ModuleIssue{
+		File:     file,
+		Line:     mci.line,
+		Message:  mci.String(),
+		RuleID:   mci.kind,
+		Severity: severity,
+		Stack:    mci.stack,
+	}
 }
 
 func (mci *memoryCheckerIssue) String() string {
@@ -72,6 +107,8 @@ func (mci *memoryCheckerIssue) String() string {
 
 type TestMemoryResult struct {
 	testName    string
+	skipped     bool
+	limit       string // hit by the run, e.g. TLE
 	criticalMsg string
 	issues      []memoryCheckerIssue
 	warnings    []memoryCheckerIssue
@@ -91,7 +128,8 @@ func (tmr *TestMemoryResult) GetStatus() TestStatus {
 		return CRITICAL
 	}
 
-	if len(tmr.issues) > 0 {
+	// A run stopped by a limit fails, its report gets the limit verdict
+	if len(tmr.issues) > 0 || tmr.limit != "" {
 		return ISSUE
 	} else if len(tmr.warnings) > 0 {
 		return WARNING
@@ -100,14 +138,31 @@ func (tmr *TestMemoryResult) GetStatus() TestStatus {
 	return OK
 }
 
+// Returns the name of the test, with the limit its run hit
+func (tmr *TestMemoryResult) title() string {
+	if tmr.limit != "" {
+		return fmt.Sprintf("%s %s", tmr.testName, tmr.limit)
+	}
+
+	return tmr.testName
+}
+
 func (tmr *TestMemoryResult) String() string {
 
+	if tmr.skipped {
+		return fmt.Sprintf("%s - SKIPPED", tmr.testName)
+	}
+
 	if tmr.GetStatus() == OK {
 		return fmt.Sprintf("%s - OK", tmr.testName)
 	}
 
 	str := strings.Builder{}
 
+	if tmr.limit != "" {
+		str.WriteString(fmt.Sprintf("%s - %s\n\n", tmr.title(), utils.LimitMessage(tmr.limit)))
+	}
+
 	if tmr.GetStatus() == CRITICAL {
 		str.WriteString(fmt.Sprintf("%s - CRITICAL ERROR\n\n", tmr.testName))
 		str.WriteString(tmr.criticalMsg + "\n")
@@ -188,10 +243,80 @@ func (mc *MemoryChecker) Score() int {
 	return int(float32(mc.score) * utils.Config.MemoryChecker.Grade)
 }
 
+func (mc *MemoryChecker) Report() ModuleReport {
+	report := newModuleReport(mc, mc.score)
+
+	// No verdicts when the module didn't run
+	if mc.status != Ready {
+		return report
+	}
+
+	for i, test := range utils.Config.Tests {
+		verdict := VerdictMissing
+		details := ""
+
+		// Results are left empty when the valgrind log couldn't be read
+		if i < len(mc.tests) && mc.tests[i].skipped {
+			verdict = VerdictSkipped
+		} else if i < len(mc.tests) && mc.tests[i].testName != "" {
+			switch mc.tests[i].GetStatus() {
+			case OK, WARNING:
+				verdict = VerdictPassed
+			case ISSUE:
+				verdict = VerdictFailed
+			case CRITICAL:
+				verdict = VerdictError
+			}
+			if verdict != VerdictPassed {
+				details = mc.tests[i].String()
+			}
+		}
+
+		// Memory tests aren't graded on their own
+		testReport := newTestReport(test, verdict, 0)
+		testReport.MaxScore = 0
+		testReport.Details = details
+
+		report.Tests = append(report.Tests, testReport)
+	}
+
+	return report
+}
+
+func (mc *MemoryChecker) ShowTest(index int) (string, bool) {
+	if index >= len(mc.tests) || mc.tests[index].testName == "" {
+		return "", false
+	}
+
+	return mc.tests[index].String(), true
+}
+
 func (mc *MemoryChecker) Panic() {
 	mc.status = Panic
 }
 
+func (mc *MemoryChecker) GetIssues() []ModuleIssue {
+	var issues []ModuleIssue
+
+	for _, test := range mc.tests {
+		if test.criticalMsg != "" {
+			issues = append(issues, ModuleIssue{
+				Message:  test.testName + ": " + test.criticalMsg,
+				Critical: true,
+				Severity: "error",
+			})
+		}
+		for _, issue := range test.issues {
+			issues = append(issues, issue.toModuleIssue("error"))
+		}
+		for _, warning := range test.warnings {
+			issues = append(issues, warning.toModuleIssue("warning"))
+		}
+	}
+
+	return issues
+}
+
 func (mc *MemoryChecker) getTotalIssues() int {
 	totalIssues := 0
 	for _, test := range mc.tests {
@@ -246,12 +371,17 @@ func (mc *MemoryChecker) Display(d *display.Display) {
 			currentCol++
 		}
 
-		cell := tview.NewTableCell(test.testName)
+		cell := tview.NewTableCell(test.title())
 
 		color := "[white]"
 
 		switch test.GetStatus() {
 		case OK:
+			if test.skipped {
+				cell.SetTextColor(tcell.ColorGray)
+				color = "[gray]"
+				break
+			}
 			cell.SetTextColor(tcell.ColorGreen)
 			color = "[green]"
 		case WARNING:
@@ -345,34 +475,47 @@ func (mc *MemoryChecker) Run() {
 		go func() {
 			defer wg.Done()
 
+			if !utils.TestSelected(test) {
+				mc.tests[i] = TestMemoryResult{testName: test.DisplayName, skipped: true}
+				return
+			}
+
 			absTempPath, err := filepath.Abs(utils.Config.TempPath)
 			if err != nil {
 				utils.Err("Failed to get absolute temp")
 				return
 			}
 
+			testResult := TestMemoryResult{testName: test.DisplayName, limit: utils.LimitOf(test.File)}
+
 			data, err := os.ReadFile(fmt.Sprintf("%s/%s.xml", absTempPath, test.File))
 			if err != nil {
 				utils.Err(fmt.Sprintf("Failed to read file: %s.xml", test.File))
+
+				// Killed before valgrind wrote its log
+				if testResult.limit != "" {
+					testResult.criticalMsg = "no valgrind log, " + utils.LimitMessage(testResult.limit)
+					mc.tests[i] = testResult
+				}
 				return
 			}
 
-			testResult := TestMemoryResult{testName: test.DisplayName}
-
 			var output ValgrindOutput
 			err = xml.Unmarshal(data, &output)
 			if err != nil {
 				testResult.criticalMsg = err.Error()
-				mc.tests = append(mc.tests, testResult)
+				mc.tests[i] = testResult
 				return
 			}
 
 			idx := len(output.Errors) - 1
 			for idx > -1 && output.Errors[idx].Kind == definitelyLeaked {
-				mci := memoryCheckerIssue{message: output.Errors[idx].XWhat.Text}
+				mci := memoryCheckerIssue{kind: output.Errors[idx].Kind, message: output.Errors[idx].XWhat.Text}
+				mci.dir = output.Errors[idx].Stack.Frames[1].Dir
 				mci.file = output.Errors[idx].Stack.Frames[1].File
 				mci.function = output.Errors[idx].Stack.Frames[1].Fn
 				mci.line = output.Errors[idx].Stack.Frames[1].Line
+				mci.stack = output.Errors[idx].Stack.Trace()
 
 				testResult.issues = append(testResult.issues, mci)
 
@@ -381,10 +524,12 @@ func (mc *MemoryChecker) Run() {
 
 			for idx > -1 {
 				if output.Errors[idx].isUserGenerated() {
-					w := memoryCheckerIssue{message: output.Errors[idx].What}
+					w := memoryCheckerIssue{kind: output.Errors[idx].Kind, message: output.Errors[idx].What}
+					w.dir = output.Errors[idx].Stack.Frames[1].Dir
 					w.file = output.Errors[idx].Stack.Frames[1].File
 					w.function = output.Errors[idx].Stack.Frames[1].Fn
 					w.line = output.Errors[idx].Stack.Frames[1].Line
+					w.stack = output.Errors[idx].Stack.Trace()
 
 					testResult.warnings = append(testResult.warnings, w)
 				}
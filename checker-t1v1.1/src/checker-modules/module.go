@@ -5,6 +5,7 @@ import (
 	"github.com/fatih/color"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type ModuleIssue struct {
@@ -14,6 +15,9 @@ type ModuleIssue struct {
 	Message     string
 	ShowLineCol bool
 	Critical    bool
+	RuleID      string   // e.g. the cppcheck id or the valgrind error kind
+	Severity    string   // error, warning, style, ...
+	Stack       []string // call stack, innermost frame first
 }
 
 type ModuleError struct {
@@ -69,9 +73,33 @@ type CheckerModule interface {
 	Disable(fail bool)
 	Enable()
 	GetStatus() ModuleStatus
+	GetIssues() []ModuleIssue
+	Report() ModuleReport
 	Panic()
 }
 
+// LateSubmitter is implemented by the modules that know when the work was
+// submitted. Returns 0 when the submission isn't late
+type LateSubmitter interface {
+	Lateness() time.Duration
+}
+
+// GradeVoider is implemented by the modules that can zero the whole grade,
+// regardless of the score obtained on the other modules
+type GradeVoider interface {
+	VoidsGrade() bool
+}
+
+// TestViewer is implemented by the modules keeping a result per test.
+// Returns false when there's no result for the test at that index
+type TestViewer interface {
+	ShowTest(index int) (string, bool)
+}
+
+func (err *ModuleError) GetIssues() []ModuleIssue {
+	return err.Issues
+}
+
 func (err *ModuleError) String() string {
 	message := err.ErrorMessage + "\n"
 
@@ -5,4 +5,5 @@ var AvailableModules = map[string]CheckerModule{
 	"memory_checker": &MemoryChecker{},
 	"style_checker":  &StyleChecker{},
 	"commit_checker": &CommitChecker{},
+	"rules_checker":  &RulesChecker{},
 }
@@ -0,0 +1,148 @@
+package checkermodules
+
+import (
+	"checker-pa/src/utils"
+	"strings"
+)
+
+// IssueReport is the machine-readable form of a ModuleIssue
+type IssueReport struct {
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Col      int      `json:"col,omitempty"`
+	Message  string   `json:"message"`
+	Critical bool     `json:"critical"`
+	RuleID   string   `json:"ruleId,omitempty"`
+	Severity string   `json:"severity,omitempty"`
+	Stack    []string `json:"stack,omitempty"`
+}
+
+// DiffChunk is a piece of the reference/output diff
+type DiffChunk struct {
+	Op   string // equal, insert or delete
+	Text string
+}
+
+// TestReport holds the verdict of a single test
+type TestReport struct {
+	Name       string      `json:"name"`
+	File       string      `json:"file"`
+	Verdict    string      `json:"verdict"`
+	Score      int         `json:"score"`
+	MaxScore   int         `json:"maxScore"`
+	DurationMs int64       `json:"durationMs"`
+	ExitCode   int         `json:"exitCode"`
+	Details    string      `json:"details,omitempty"` // why the test failed
+	Group      string      `json:"group,omitempty"`   // its score only counts through the group
+	Diff       []DiffChunk `json:"-"`
+}
+
+// GroupReport holds the score of a test group
+type GroupReport struct {
+	Name     string   `json:"name"`
+	Policy   string   `json:"policy"`
+	Score    int      `json:"score"`
+	MaxScore int      `json:"maxScore"`
+	Passed   int      `json:"passed"`
+	Total    int      `json:"total"` // tests selected by the filter
+	Tests    []string `json:"tests"`
+}
+
+const (
+	VerdictPassed  = "passed"
+	VerdictFailed  = "failed"
+	VerdictMissing = "missing" // the output file couldn't be read
+	VerdictError   = "error"   // the checker couldn't process the test
+	VerdictTimeout = "timeout" // past -timeout or the cpu limit
+	VerdictMemory  = "memory_limit"
+	VerdictOutput  = "output_limit"
+	VerdictSkipped = "skipped" // left out by the test filter
+)
+
+type ModuleReport struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Score    int           `json:"score"`    // weighted by the module grade
+	RawScore int           `json:"rawScore"` // out of 100
+	Result   string        `json:"result"`
+	Issues   []IssueReport `json:"issues"`
+	Tests    []TestReport  `json:"tests,omitempty"`
+	Groups   []GroupReport `json:"groups,omitempty"`
+}
+
+// Stable status names, the display strings may change
+func statusName(status ModuleStatus) string {
+	switch status {
+	case Ready:
+		return "ready"
+	case Queued:
+		return "queued"
+	case Running:
+		return "running"
+	case Disabled:
+		return "disabled"
+	case DependencyFail:
+		return "dependency_fail"
+	case Panic:
+		return "panic"
+	default:
+		return "unknown"
+	}
+}
+
+// Fills in the fields common to every module
+func newModuleReport(cm CheckerModule, rawScore int) ModuleReport {
+	report := ModuleReport{
+		Name:     cm.GetName(),
+		Status:   statusName(cm.GetStatus()),
+		Score:    cm.Score(),
+		RawScore: rawScore,
+		Result:   utils.StripANSI(cm.GetResult()),
+		Issues:   []IssueReport{},
+	}
+
+	for _, issue := range cm.GetIssues() {
+		report.Issues = append(report.Issues, IssueReport{
+			File:     issue.File,
+			Line:     issue.Line,
+			Col:      issue.Col,
+			Message:  strings.TrimSpace(utils.StripANSI(issue.Message)),
+			Critical: issue.Critical,
+			RuleID:   issue.RuleID,
+			Severity: issue.Severity,
+			Stack:    issue.Stack,
+		})
+	}
+
+	return report
+}
+
+// Fills in the test data recorded by the manager
+func newTestReport(test utils.Test, verdict string, score int) TestReport {
+	report := TestReport{
+		Name:     test.DisplayName,
+		File:     test.File,
+		Verdict:  verdict,
+		Score:    score,
+		MaxScore: test.Score,
+		ExitCode: -1,
+	}
+
+	if run, ok := utils.GetRun(test.File); ok {
+		report.DurationMs = run.Duration.Milliseconds()
+		report.ExitCode = run.ExitCode
+
+		if verdict != VerdictPassed && verdict != VerdictSkipped {
+			switch run.Limit {
+			case utils.LimitTime:
+				report.Verdict = VerdictTimeout
+			case utils.LimitMemory:
+				report.Verdict = VerdictMemory
+			case utils.LimitOutput:
+				report.Verdict = VerdictOutput
+			}
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,471 @@
+package checkermodules
+
+import (
+	"checker-pa/src/display"
+	"checker-pa/src/utils"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/rivo/tview"
+)
+
+const (
+	defaultRulePenalty = 10
+)
+
+var (
+	identifierRegex = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+	includeRegex    = regexp.MustCompile(`^\s*#\s*include\s*[<"]([^>"]+)[>"]`)
+)
+
+type RulesChecker struct {
+	ModuleOutput
+	score  int
+	voided bool
+	status ModuleStatus
+}
+
+// Holds a scanned source file, stripped of comments and literals
+type sourceFile struct {
+	path    string
+	lines   []string // comments and literals stripped
+	headers []string // comments stripped, literals kept
+}
+
+func (*RulesChecker) GetName() string {
+	return "RULES"
+}
+
+func (*RulesChecker) IsOutputDependent() bool {
+	if utils.Config.RulesChecker == nil {
+		return false
+	}
+	return utils.Config.RulesChecker.OutputDependent
+}
+
+func (*RulesChecker) GetDependencies() []string { return nil }
+
+func (rc *RulesChecker) Disable(fail bool) {
+	if fail {
+		rc.status = DependencyFail
+	} else {
+		rc.status = Disabled
+	}
+}
+
+func (rc *RulesChecker) Enable() {
+	rc.status = Queued
+}
+
+func (rc *RulesChecker) GetStatus() ModuleStatus {
+	return rc.status
+}
+
+func (rc *RulesChecker) GetResult() string {
+	if rc.voided {
+		return color.New(color.FgHiRed).Sprintf("%d violations", len(rc.Issues))
+	}
+	return fmt.Sprintf("%d violations", len(rc.Issues))
+}
+
+func (rc *RulesChecker) Report() ModuleReport {
+	return newModuleReport(rc, rc.score)
+}
+
+func (rc *RulesChecker) Panic() {
+	rc.status = Panic
+}
+
+func (rc *RulesChecker) VoidsGrade() bool {
+	return rc.status == Ready && rc.voided
+}
+
+func (rc *RulesChecker) Reset() {
+	// The module is optional, keep it disabled when it's missing from the config
+	if utils.Config.RulesChecker == nil {
+		rc.status = Disabled
+		return
+	}
+	if rc.status == Disabled || rc.status == DependencyFail {
+		return
+	}
+	rc.Issues = nil
+	rc.score = 0
+	rc.voided = false
+	rc.status = Queued
+}
+
+func (rc *RulesChecker) Score() int {
+	if utils.Config.RulesChecker == nil {
+		return 0
+	}
+	return int(float32(rc.score) * utils.Config.RulesChecker.Grade)
+}
+
+func (rc *RulesChecker) Display(d *display.Display) {
+
+	d.CurrentContainer().Title("Rules checker - "+strconv.Itoa(rc.Score()), tview.AlignLeft)
+
+	// Disable border
+	d.PrintPage(0, "$nb", "")
+
+	if statusStr := StatusStr(rc); statusStr != "" {
+		d.PrintPage(0, "$nb", statusStr)
+		return
+	}
+
+	if len(rc.Issues) == 0 {
+		d.Println("No forbidden constructs found!")
+		return
+	}
+
+	if rc.voided {
+		d.Println(color.New(color.FgHiRed).Sprint("A critical rule was broken, the grade is void!") + "\n")
+	}
+
+	for _, issue := range rc.Issues {
+		d.Println(issue.Message + "\n")
+	}
+}
+
+func (rc *RulesChecker) Dump() {
+	fmt.Printf("===== Rules checker - %d =====\n\n", rc.Score())
+
+	if rc.status != Ready {
+		fmt.Println("This module is disabled.")
+		return
+	}
+
+	if len(rc.Issues) == 0 {
+		fmt.Println("No forbidden constructs found!")
+		fmt.Println()
+		return
+	}
+
+	if rc.voided {
+		fmt.Println("A critical rule was broken, the grade is void!")
+	}
+
+	fmt.Println(rc.ModuleError.String())
+	fmt.Println()
+}
+
+func (rc *RulesChecker) Run() {
+	rc.status = Running
+
+	config := utils.Config.RulesChecker
+
+	// Not the student's fault, the module panics instead of deducting points
+	files, err := scanSources(utils.Config.SourcePath)
+	if err != nil {
+		utils.Err("failed reading the sources: " + err.Error())
+		rc.Panic()
+		return
+	}
+	defer func() { rc.status = Ready }()
+
+	for _, rule := range config.BannedHeaders {
+		rc.checkBannedHeader(files, rule)
+	}
+
+	for _, rule := range config.BannedIdentifiers {
+		rc.checkBannedIdentifier(files, rule)
+	}
+
+	for _, rule := range config.Required {
+		rc.checkRequired(files, rule)
+	}
+
+	penalty := config.Penalty
+	if penalty == 0 {
+		penalty = defaultRulePenalty
+	}
+
+	rc.score = max(100-len(rc.Issues)*penalty, 0)
+	if rc.voided {
+		rc.score = 0
+	}
+}
+
+// Records a violation of the given rule, pointing at the offending source
+func (rc *RulesChecker) addViolation(rule utils.Rule, ruleID string, file string, line int, col int, what string) {
+	severityColor := color.New(color.FgRed)
+	if rule.Critical {
+		rc.voided = true
+		severityColor = color.New(color.FgHiRed)
+	}
+
+	label := "violation"
+	severity := "warning"
+	if rule.Critical {
+		label = "critical"
+		severity = "error"
+	}
+
+	message := what
+	if rule.Message != "" {
+		message += " (" + rule.Message + ")"
+	}
+
+	if file != "" {
+		message = fmt.Sprintf("%s:%d:%d: %s: %s", file, line, col, severityColor.Add(color.Bold).Sprint(label), message)
+		if lineWithPointer, err := readLineAndCreatePointer(file, line, col, color.New(color.FgRed)); err == nil {
+			message += "\n" + lineWithPointer
+		}
+	} else {
+		message = fmt.Sprintf("%s: %s", severityColor.Add(color.Bold).Sprint(label), message)
+	}
+
+	rc.Issues = append(rc.Issues, ModuleIssue{
+		File:     file,
+		Line:     line,
+		Col:      col,
+		Message:  message,
+		Critical: rule.Critical,
+		RuleID:   ruleID,
+		Severity: severity,
+	})
+}
+
+func (rc *RulesChecker) checkBannedHeader(files []sourceFile, rule utils.Rule) {
+	// Checked with the config
+	pattern, err := compileRule(rule)
+	if err != nil {
+		utils.Err(err.Error())
+		return
+	}
+
+	for _, file := range files {
+		for i, line := range file.headers {
+			match := includeRegex.FindStringSubmatchIndex(line)
+			if match == nil {
+				continue
+			}
+
+			header := line[match[2]:match[3]]
+			if (pattern != nil && pattern.MatchString(header)) || (pattern == nil && header == rule.Name) {
+				rc.addViolation(rule, "banned-header", file.path, i+1, match[2]+1, fmt.Sprintf("banned header <%s>", header))
+			}
+		}
+	}
+}
+
+func (rc *RulesChecker) checkBannedIdentifier(files []sourceFile, rule utils.Rule) {
+	// Checked with the config
+	pattern, err := compileRule(rule)
+	if err != nil {
+		utils.Err(err.Error())
+		return
+	}
+
+	for _, file := range files {
+		for i, line := range file.lines {
+			if pattern != nil {
+				for _, loc := range pattern.FindAllStringIndex(line, -1) {
+					rc.addViolation(rule, "banned-construct", file.path, i+1, loc[0]+1, fmt.Sprintf("banned construct \"%s\"", line[loc[0]:loc[1]]))
+				}
+				continue
+			}
+
+			for _, loc := range identifierRegex.FindAllStringIndex(line, -1) {
+				if line[loc[0]:loc[1]] == rule.Name {
+					rc.addViolation(rule, "banned-identifier", file.path, i+1, loc[0]+1, fmt.Sprintf("banned identifier \"%s\"", rule.Name))
+				}
+			}
+		}
+	}
+}
+
+func (rc *RulesChecker) checkRequired(files []sourceFile, rule utils.Rule) {
+	// Checked with the config
+	pattern, err := compileRule(rule)
+	if err != nil {
+		utils.Err(err.Error())
+		return
+	}
+
+	if pattern != nil {
+		for _, file := range files {
+			for _, line := range file.lines {
+				if pattern.MatchString(line) {
+					return
+				}
+			}
+		}
+
+		rc.addViolation(rule, "required-construct", "", 0, 0, fmt.Sprintf("required construct \"%s\" not found", rule.Pattern))
+		return
+	}
+
+	found := findIdentifier(files, rule.Name)
+
+	if rule.Per == "" {
+		if len(found) == 0 {
+			rc.addViolation(rule, "required-identifier", "", 0, 0, fmt.Sprintf("required identifier \"%s\" not found", rule.Name))
+		}
+		return
+	}
+
+	// Point at the first occurrence that isn't matched
+	per := findIdentifier(files, rule.Per)
+	if len(found) < len(per) {
+		unmatched := per[len(found)]
+		rc.addViolation(rule, "unmatched-identifier", unmatched.File, unmatched.Line, unmatched.Col,
+			fmt.Sprintf("found %d \"%s\" for %d \"%s\"", len(found), rule.Name, len(per), rule.Per))
+	}
+}
+
+// Returns the regex of a rule, or nil when the rule uses a plain name
+func compileRule(rule utils.Rule) (*regexp.Regexp, error) {
+	if rule.Pattern == "" {
+		return nil, nil
+	}
+
+	pattern, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule pattern \"%s\": %w", rule.Pattern, err)
+	}
+
+	return pattern, nil
+}
+
+func findIdentifier(files []sourceFile, name string) []ModuleIssue {
+	var found []ModuleIssue
+
+	for _, file := range files {
+		for i, line := range file.lines {
+			for _, loc := range identifierRegex.FindAllStringIndex(line, -1) {
+				if line[loc[0]:loc[1]] == name {
+					found = append(found, ModuleIssue{File: file.path, Line: i + 1, Col: loc[0] + 1})
+				}
+			}
+		}
+	}
+
+	return found
+}
+
+// Collects all the C sources found inside the source path
+func scanSources(root string) ([]sourceFile, error) {
+	var files []sourceFile
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			// Skip hidden directories such as .git or the checker temp path
+			if path != root && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !isSourceFile(path) {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, sourceFile{
+			path:    path,
+			lines:   strings.Split(stripSource(string(data), false), "\n"),
+			headers: strings.Split(stripSource(string(data), true), "\n"),
+		})
+
+		return nil
+	})
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path < files[j].path
+	})
+
+	return files, err
+}
+
+func isSourceFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".c" || ext == ".h"
+}
+
+// Blanks out the comments and, unless keepLiterals is set, the contents of
+// string and char literals. Newlines and columns are left in place
+func stripSource(src string, keepLiterals bool) string {
+	const (
+		code = iota
+		lineComment
+		blockComment
+		literal
+	)
+
+	out := []byte(src)
+	state := code
+	var quote byte
+
+	for i := 0; i < len(out); i++ {
+		c := src[i]
+		var next byte
+		if i+1 < len(src) {
+			next = src[i+1]
+		}
+
+		switch state {
+		case code:
+			switch {
+			case c == '/' && next == '/':
+				state = lineComment
+				out[i] = ' '
+			case c == '/' && next == '*':
+				state = blockComment
+				out[i], out[i+1] = ' ', ' '
+				i++
+			case c == '"' || c == '\'':
+				state = literal
+				quote = c
+			}
+		case lineComment:
+			if c == '\n' {
+				state = code
+			} else {
+				out[i] = ' '
+			}
+		case blockComment:
+			if c == '*' && next == '/' {
+				state = code
+				out[i], out[i+1] = ' ', ' '
+				i++
+			} else if c != '\n' {
+				out[i] = ' '
+			}
+		case literal:
+			switch {
+			case c == quote || c == '\n':
+				state = code
+			case c == '\\' && next != '\n':
+				if !keepLiterals {
+					out[i] = ' '
+					// The source may end inside the literal
+					if i+1 < len(out) {
+						out[i+1] = ' '
+					}
+				}
+				i++
+			case !keepLiterals:
+				out[i] = ' '
+			}
+		}
+	}
+
+	return string(out)
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
 	"slices"
 	"sort"
 	"strconv"
@@ -20,8 +21,17 @@ import (
 	"github.com/fatih/color"
 )
 
+// Matches "// checker-ignore: <id>[,<id>...] reason" comments
+var suppressionRegex = regexp.MustCompile(`//\s*checker-ignore:\s*([\w*,-]+)\s*(.*)$`)
+
+type suppressedIssue struct {
+	ModuleIssue
+	reason string
+}
+
 type StyleChecker struct {
 	ModuleOutput
+	suppressed []suppressedIssue
 	totalScore int
 	status     ModuleStatus
 }
@@ -53,9 +63,16 @@ func (sc *StyleChecker) GetStatus() ModuleStatus {
 }
 
 func (sc *StyleChecker) GetResult() string {
+	if len(sc.suppressed) > 0 {
+		return fmt.Sprintf("%d issues (%d suppressed)", len(sc.Issues), len(sc.suppressed))
+	}
 	return fmt.Sprintf("%d issues", len(sc.Issues))
 }
 
+func (sc *StyleChecker) Report() ModuleReport {
+	return newModuleReport(sc, max(sc.totalScore, 0))
+}
+
 func (sc *StyleChecker) Panic() {
 	sc.status = Panic
 }
@@ -86,7 +103,12 @@ func (sc *StyleChecker) Display(d *display.Display) {
 
 	fileTable := tview.NewTable()
 
-	fileTable.SetInputCapture(utils.TableSelector(len(groups), fileTable))
+	itemCount := len(groups)
+	if len(sc.suppressed) > 0 {
+		itemCount++
+	}
+
+	fileTable.SetInputCapture(utils.TableSelector(itemCount, fileTable))
 
 	var keys []string
 
@@ -134,6 +156,29 @@ func (sc *StyleChecker) Display(d *display.Display) {
 		fileTable.SetCell(i, 0, cell)
 	}
 
+	if len(sc.suppressed) > 0 {
+		cell := tview.NewTableCell(fmt.Sprintf("Suppressed (%d)", len(sc.suppressed)))
+
+		cell.SetTextColor(tcell.ColorGray)
+
+		cell.SetSelectable(true)
+		cell.SetClickedFunc(func() bool {
+
+			d.NewPage("[gray]Suppressed findings", true)
+			d.CurrentContainer().SetDirection(tview.FlexColumn)
+			d.AddWritableContainer(d.CurrentContainer(), 0, 1)
+
+			d.PrintPage(0, "$nb", "")
+			d.Println(sc.suppressedString())
+
+			d.App.SetFocus(d.CurrentContainer().Container)
+			d.CurrentContainer().WrapInput(d.CurrentContainer().Sections[0])
+
+			return false
+		})
+		fileTable.SetCell(len(keys), 0, cell)
+	}
+
 	firstCell := fileTable.GetCell(0, 0)
 
 	textColor, _, _ := firstCell.Style.Decompose()
@@ -156,6 +201,41 @@ func (sc *StyleChecker) Dump() {
 
 	fmt.Println(sc.ModuleError.String())
 	fmt.Println()
+
+	if len(sc.suppressed) > 0 {
+		fmt.Println("===== Suppressed findings =====")
+		fmt.Println()
+		fmt.Println(sc.suppressedString())
+	}
+}
+
+// Returns the number of suppressions over the cap, none without one
+func (sc *StyleChecker) excessSuppressions() int {
+	limit := utils.Config.StyleChecker.MaxSuppressions
+	if limit == nil {
+		return 0
+	}
+
+	return max(len(sc.suppressed)-*limit, 0)
+}
+
+func (sc *StyleChecker) suppressedString() string {
+	str := strings.Builder{}
+
+	if excess := sc.excessSuppressions(); excess > 0 {
+		str.WriteString(fmt.Sprintf("%d suppressions allowed, the other %d count as issues.\n\n",
+			len(sc.suppressed)-excess, excess))
+	}
+
+	for _, issue := range sc.suppressed {
+		reason := issue.reason
+		if reason == "" {
+			reason = "no reason given"
+		}
+		str.WriteString(fmt.Sprintf("%s:%d:%d: [%s] %s\n", issue.File, issue.Line, issue.Col, issue.RuleID, reason))
+	}
+
+	return str.String()
 }
 
 func (sc *StyleChecker) Reset() {
@@ -163,6 +243,7 @@ func (sc *StyleChecker) Reset() {
 		return
 	}
 	sc.Issues = nil
+	sc.suppressed = nil
 	sc.totalScore = 0
 	sc.status = Queued
 }
@@ -236,12 +317,28 @@ func (sc *StyleChecker) Run() {
 		return
 	}
 
+	suppressions := make(map[string][]string)
+
 	// Convert cppcheck errors to module issues
 	for _, err := range results.Errors {
 		for _, loc := range err.Locations {
+			if reason, ok := findSuppression(suppressions, loc.File, loc.Line, err.ID); ok {
+				sc.suppressed = append(sc.suppressed, suppressedIssue{
+					ModuleIssue: ModuleIssue{
+						File:     loc.File,
+						Line:     loc.Line,
+						Col:      loc.Column,
+						RuleID:   err.ID,
+						Severity: err.Severity,
+					},
+					reason: reason,
+				})
+				continue
+			}
+
 			// Read the line content from the file and create pointer
 			severityColor := sc.getSeverityColor(err.Severity)
-			lineWithPointer, readErr := sc.readLineAndCreatePointer(loc.File, loc.Line, loc.Column, severityColor)
+			lineWithPointer, readErr := readLineAndCreatePointer(loc.File, loc.Line, loc.Column, severityColor)
 
 			var message string
 			if readErr != nil {
@@ -266,6 +363,8 @@ func (sc *StyleChecker) Run() {
 				Col:         int(loc.Column),
 				Message:     message,
 				ShowLineCol: false,
+				RuleID:      err.ID,
+				Severity:    err.Severity,
 			})
 		}
 	}
@@ -283,8 +382,11 @@ func (sc *StyleChecker) calculateScore() {
 		return a.Under - b.Under
 	})
 
+	// Suppressions over the cap are penalized like regular issues
+	issueCount := len(sc.Issues) + sc.excessSuppressions()
+
 	for _, threshold := range utils.Config.Thresholds {
-		if threshold.Under >= len(sc.Issues) {
+		if threshold.Under >= issueCount {
 			sc.totalScore = threshold.Score
 			break
 		}
@@ -293,7 +395,7 @@ func (sc *StyleChecker) calculateScore() {
 
 // Implementation of error formatting inspired by
 // https://github.com/danmar/cppcheck/blob/main/lib/errorlogger.cpp
-func (sc *StyleChecker) readLineAndCreatePointer(filePath string, lineNum int, column int, severityColor *color.Color) (string, error) {
+func readLineAndCreatePointer(filePath string, lineNum int, column int, severityColor *color.Color) (string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return "", err
@@ -333,6 +435,47 @@ func (sc *StyleChecker) readLineAndCreatePointer(filePath string, lineNum int, c
 	return "", fmt.Errorf("line %d not found", lineNum)
 }
 
+// Looks for a "checker-ignore" comment matching the id, either at the end of
+// the line or alone on the line above. The file lines are cached in lines
+func findSuppression(lines map[string][]string, filePath string, lineNum int, id string) (string, bool) {
+	if _, ok := lines[filePath]; !ok {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			lines[filePath] = nil
+		} else {
+			lines[filePath] = strings.Split(string(data), "\n")
+		}
+	}
+
+	fileLines := lines[filePath]
+
+	for _, idx := range []int{lineNum - 1, lineNum - 2} {
+		if idx < 0 || idx >= len(fileLines) {
+			continue
+		}
+
+		line := fileLines[idx]
+
+		// A comment above only applies if it's alone on its line
+		if idx == lineNum-2 && !strings.HasPrefix(strings.TrimSpace(line), "//") {
+			continue
+		}
+
+		match := suppressionRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		for _, ignored := range strings.Split(match[1], ",") {
+			if ignored == id || ignored == "*" {
+				return strings.TrimSpace(match[2]), true
+			}
+		}
+	}
+
+	return "", false
+}
+
 func (sc *StyleChecker) getSeverityColor(severity string) *color.Color {
 	switch severity {
 	case "error":
@@ -0,0 +1,99 @@
+package manager
+
+import (
+	"checker-pa/src/utils"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+)
+
+// Diagnosis is the outcome of a single doctor check
+type Diagnosis struct {
+	Name string
+	Err  error
+	// Warnings don't prevent the checker from running
+	Warning bool
+}
+
+func checkDir(path string) error {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !stat.IsDir() {
+		return errors.New(path + " is not a directory")
+	}
+
+	return nil
+}
+
+// Created on the first run, only the parent has to exist
+func checkCreatableDir(path string) error {
+	if err := checkDir(path); err == nil {
+		return nil
+	}
+
+	return checkDir(filepath.Dir(filepath.Clean(path)))
+}
+
+// Doctor checks the dependencies, the paths and the test files
+func (m *Manager) Doctor() []Diagnosis {
+	var diagnoses []Diagnosis
+
+	add := func(name string, err error, warning bool) {
+		diagnoses = append(diagnoses, Diagnosis{Name: name, Err: err, Warning: warning})
+	}
+
+	// Already validated when loaded
+	add("module config "+utils.Config.ModuleConfigSource, nil, false)
+
+	_, err := exec.LookPath(utils.Config.ExecutablePath)
+	add("executable "+utils.Config.ExecutablePath, err, true)
+
+	var dependencies []string
+	for _, module := range m.Modules {
+		for _, dependency := range module.GetDependencies() {
+			if !slices.Contains(dependencies, dependency) {
+				dependencies = append(dependencies, dependency)
+			}
+		}
+	}
+
+	for _, dependency := range dependencies {
+		_, err := exec.LookPath(dependency)
+		add("dependency "+dependency, err, false)
+	}
+
+	add("source path "+utils.Config.SourcePath, checkDir(utils.Config.SourcePath), false)
+	add("input path "+utils.Config.InputPath, checkDir(utils.Config.InputPath), false)
+	add("ref path "+utils.Config.RefPath, checkDir(utils.Config.RefPath), false)
+	add("output path "+utils.Config.OutputPath, checkCreatableDir(utils.Config.OutputPath), false)
+	add("forward path "+utils.Config.ForwardPath, checkCreatableDir(utils.Config.ForwardPath), false)
+	add("temp path "+utils.Config.TempPath, checkCreatableDir(utils.Config.TempPath), false)
+
+	missingRefs := 0
+	for _, test := range utils.Config.Tests {
+		refPath := test.RefFile()
+		if _, err := os.Stat(refPath); err != nil {
+			add("reference "+refPath, err, false)
+			missingRefs++
+		}
+	}
+	if missingRefs == 0 {
+		add(fmt.Sprintf("references of the %d tests", len(utils.Config.Tests)), nil, false)
+	}
+
+	for _, warning := range utils.DiscoveryWarnings {
+		add("test discovery", errors.New(warning), true)
+	}
+
+	if utils.Config.Deadline != "" {
+		_, err := utils.ParseDeadline(utils.Config.Deadline)
+		add("deadline "+utils.Config.Deadline, err, false)
+	}
+
+	return diagnoses
+}
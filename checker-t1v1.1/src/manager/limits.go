@@ -0,0 +1,36 @@
+package manager
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+var errOutputLimit = errors.New("output limit exceeded")
+
+// Keeps the first bytes written, failing once full so the pipe of the test is closed.
+// The buffer isn't embedded, its ReadFrom would let io.Copy go around the limit
+type cappedBuffer struct {
+	buf      bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+func newCappedBuffer(limitKiB int) *cappedBuffer {
+	return &cappedBuffer{limit: limitKiB * 1024}
+}
+
+func (cb *cappedBuffer) Write(p []byte) (int, error) {
+	if cb.exceeded {
+		return 0, errOutputLimit
+	}
+
+	if room := cb.limit - cb.buf.Len(); len(p) > room {
+		cb.buf.Write(p[:room])
+		cb.exceeded = true
+		fmt.Fprintf(&cb.buf, "\n[truncated after %d KiB]\n", cb.limit/1024)
+		return room, errOutputLimit
+	}
+
+	return cb.buf.Write(p)
+}
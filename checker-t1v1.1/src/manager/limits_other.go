@@ -0,0 +1,23 @@
+//go:build !unix
+
+package manager
+
+import (
+	"checker-pa/src/utils"
+	"os"
+	"os/exec"
+)
+
+// Only the output limit applies without rlimits
+func limitCommand(cmd *exec.Cmd, limits utils.Limits) {
+	if limits != (utils.Limits{Output: limits.Output}) {
+		utils.Log("the resource limits aren't supported on this system, only the output is capped")
+	}
+}
+
+// RunLimitHelper does nothing, the checker never re-executes itself to apply the limits here
+func RunLimitHelper() {}
+
+func peakMemory(*os.ProcessState) int64 { return 0 }
+
+func runLimit(utils.Limits, *os.ProcessState) string { return "" }
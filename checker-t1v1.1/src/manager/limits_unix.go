@@ -0,0 +1,144 @@
+//go:build unix
+
+package manager
+
+import (
+	"checker-pa/src/utils"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// First argument of the checker when it re-executes itself to apply the limits of a test
+const limitHelper = "__apply-limits"
+
+// Runs the command through the checker itself, which sets the rlimits before exec,
+// so they hold from the first instruction of the test
+func limitCommand(cmd *exec.Cmd, limits utils.Limits) {
+	if cmd.Err != nil || limits == (utils.Limits{Output: limits.Output}) {
+		return
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		cmd.Err = fmt.Errorf("failed applying the limits: %w", err)
+		return
+	}
+
+	spec := fmt.Sprintf("%d,%d,%d,%d,%d", limits.CPU, limits.Memory, limits.FileSize, limits.Processes, limits.OpenFiles)
+	cmd.Args = append([]string{self, limitHelper, spec, cmd.Path}, cmd.Args...)
+	cmd.Path = self
+}
+
+// Sets the limit, without going over the hard limit the checker itself has
+func setLimit(resource int, soft uint64, hard uint64) error {
+	var current syscall.Rlimit
+	if err := syscall.Getrlimit(resource, &current); err != nil {
+		return err
+	}
+
+	limit := syscall.Rlimit{Cur: min(soft, current.Max), Max: min(hard, current.Max)}
+	return syscall.Setrlimit(resource, &limit)
+}
+
+// RunLimitHelper applies the limits and executes the test when the checker is started by
+// limitCommand, as "checker __apply-limits cpu,memory,fileSize,processes,openFiles path argv...".
+// Returns right away otherwise
+func RunLimitHelper() {
+	if len(os.Args) < 5 || os.Args[1] != limitHelper {
+		return
+	}
+
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "checker: %s\n", err)
+		os.Exit(126)
+	}
+
+	var cpu, memory, fileSize, processes, openFiles uint64
+	if _, err := fmt.Sscanf(os.Args[2], "%d,%d,%d,%d,%d", &cpu, &memory, &fileSize, &processes, &openFiles); err != nil {
+		fail(fmt.Errorf("invalid limits %q: %w", os.Args[2], err))
+	}
+
+	const mib = 1024 * 1024
+
+	for _, limit := range []struct {
+		resource   int
+		value      uint64
+		soft, hard uint64
+	}{
+		// SIGXCPU at the soft limit, SIGKILL a second later if it's ignored
+		{syscall.RLIMIT_CPU, cpu, cpu, cpu + 1},
+		{syscall.RLIMIT_AS, memory, memory * mib, memory * mib},
+		{syscall.RLIMIT_FSIZE, fileSize, fileSize * mib, fileSize * mib},
+		{unix.RLIMIT_NPROC, processes, processes, processes},
+		{syscall.RLIMIT_NOFILE, openFiles, openFiles, openFiles},
+	} {
+		if limit.value == 0 {
+			continue
+		}
+		if err := setLimit(limit.resource, limit.soft, limit.hard); err != nil {
+			fail(fmt.Errorf("failed setting the limits: %w", err))
+		}
+	}
+
+	fail(syscall.Exec(os.Args[3], os.Args[4:], os.Environ()))
+}
+
+// Tenths of the memory limit a crashed run must have used to be taken as out of memory
+const nearLimit = 8
+
+// Returns the KiB of resident memory used at most by the run
+func peakMemory(state *os.ProcessState) int64 {
+	if state == nil {
+		return 0
+	}
+
+	usage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok {
+		return 0
+	}
+
+	// In bytes on macOS
+	if runtime.GOOS == "darwin" {
+		return int64(usage.Maxrss) / 1024
+	}
+
+	return int64(usage.Maxrss)
+}
+
+// Returns the limit that ended the run, if any
+func runLimit(limits utils.Limits, state *os.ProcessState) string {
+	if state == nil {
+		return ""
+	}
+
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+
+	switch status.Signal() {
+	case syscall.SIGXCPU:
+		return utils.LimitTime
+	case syscall.SIGXFSZ:
+		return utils.LimitOutput
+	case syscall.SIGKILL:
+		if limits.CPU > 0 && state.UserTime()+state.SystemTime() >= time.Duration(limits.CPU)*time.Second {
+			return utils.LimitTime
+		}
+	case syscall.SIGSEGV, syscall.SIGBUS, syscall.SIGABRT:
+		// Allocations beyond the address space fail instead of killing the process, which then
+		// crashes on the null pointer or aborts, e.g. std::bad_alloc. The libraries and the stack
+		// take part of the address space, so the resident memory is near the limit, not at it
+		if limits.Memory > 0 && peakMemory(state)*10 >= int64(limits.Memory)*1024*nearLimit {
+			return utils.LimitMemory
+		}
+	}
+
+	return ""
+}
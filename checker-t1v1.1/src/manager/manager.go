@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"checker-pa/src/checker-modules"
 	"checker-pa/src/utils"
+	"context"
 	"errors"
 	"fmt"
 	"math"
@@ -20,6 +21,18 @@ import (
 type Manager struct {
 	Modules []checkermodules.CheckerModule
 
+	// Duration of the last run
+	Elapsed time.Duration
+
+	// Maximum running time of a test, no limit when 0
+	Timeout time.Duration
+
+	// Names or config keys of the modules to run, all of them when empty
+	ModuleFilter []string
+
+	durations   map[string]time.Duration
+	durationsMu sync.Mutex
+
 	capabilities map[string]bool
 
 	StatusPing func(caption string)
@@ -41,7 +54,14 @@ func (m *Manager) BasicSummary(caption string) {
 		}
 	}
 
-	summary.WriteString(fmt.Sprintf("\nScore: %d\n", m.TotalScore()))
+	summary.WriteString(fmt.Sprintf("\nConfig: %s\n", utils.Config.ModuleConfigSource))
+	summary.WriteString(fmt.Sprintf("Score: %d\n", m.TotalScore()))
+	if late := m.LateSummary(); late != "" {
+		summary.WriteString(late + "\n")
+	}
+	if m.IsVoided() {
+		summary.WriteString("A critical rule was broken, the grade is void!\n")
+	}
 
 	fmt.Println(summary.String())
 }
@@ -122,41 +142,98 @@ func NewManager() (*Manager, error) {
 		return nil, err
 	}
 
-	go func() {
+	if err := utils.DiscoverTests(); err != nil {
+		return nil, err
+	}
 
-		prevPath := abs(utils.Config.ExecutablePath)
-		prevStat, err := os.Stat(prevPath)
-		for {
-			currentPath := abs(utils.Config.ExecutablePath)
-			currentStat, err2 := os.Stat(currentPath)
-			if (err != nil && err2 == nil) || (err == nil && err2 == nil && (prevPath != currentPath || prevStat.ModTime().Before(currentStat.ModTime()))) {
-				utils.Log("file change detected!")
-				err := m.Run()
-				if err != nil {
-					utils.Err("failed manager run with error: " + err.Error())
-				}
-			}
-			/*
-				if err != nil && err2 == nil {
-					// Something happened here, maybe the file got created or idk
+	if err := utils.ResolveGroups(); err != nil {
+		return nil, err
+	}
 
-				} else if err == nil && err2 == nil {
-					if prevPath != currentPath {
+	return &m, nil
+}
 
-					} else if prevStat.ModTime().Before(currentStat.ModTime()) {
-						// prev file is modified before current, but how can we know if they're the same
-					}
-				}
-			*/
+// Returns whether the module is selected by the module filter,
+// either by its name or by its config key
+func (m *Manager) moduleSelected(module checkermodules.CheckerModule) bool {
+	if len(m.ModuleFilter) == 0 {
+		return true
+	}
 
-			prevPath = currentPath
-			prevStat, err = currentStat, err2
+	for _, name := range m.ModuleFilter {
+		if strings.EqualFold(name, module.GetName()) {
+			return true
+		}
+		if checkermodules.AvailableModules[name] == module {
+			return true
+		}
+	}
 
-			time.Sleep(2 * time.Second)
+	return false
+}
+
+// Disables the modules left out by the module filter
+func (m *Manager) applyModuleFilter() {
+	for _, module := range m.Modules {
+		if !m.moduleSelected(module) {
+			module.Disable(false)
 		}
-	}()
+	}
+}
 
-	return &m, nil
+// SetModuleFilter selects the modules to run, by name (MEMORY) or by config key (memory_checker)
+func (m *Manager) SetModuleFilter(names []string) error {
+	for _, name := range names {
+		known := checkermodules.AvailableModules[name] != nil
+		for _, module := range m.Modules {
+			known = known || strings.EqualFold(name, module.GetName())
+		}
+
+		if !known {
+			return errors.New("unknown module " + name)
+		}
+	}
+
+	m.ModuleFilter = names
+	return nil
+}
+
+// Watch reruns the checker whenever the executable changes, calling onChange after every run.
+// It never returns
+func (m *Manager) Watch(onChange func()) {
+	prevPath := abs(utils.Config.ExecutablePath)
+	prevStat, err := os.Stat(prevPath)
+	for {
+		currentPath := abs(utils.Config.ExecutablePath)
+		currentStat, err2 := os.Stat(currentPath)
+		if (err != nil && err2 == nil) || (err == nil && err2 == nil && (prevPath != currentPath || prevStat.ModTime().Before(currentStat.ModTime()))) {
+			utils.Log("file change detected!")
+			err := m.Run()
+			if err != nil {
+				utils.Err("failed manager run with error: " + err.Error())
+			}
+			if onChange != nil {
+				onChange()
+			}
+		}
+		/*
+			if err != nil && err2 == nil {
+				// Something happened here, maybe the file got created or idk
+
+			} else if err == nil && err2 == nil {
+				if prevPath != currentPath {
+
+				} else if prevStat.ModTime().Before(currentStat.ModTime()) {
+					// prev file is modified before current, but how can we know if they're the same
+				}
+			}
+		*/
+
+		prevPath = currentPath
+		prevStat, err = currentStat, err2
+
+		time.Sleep(2 * time.Second)
+	}
 }
 
 func (m *Manager) register(module checkermodules.CheckerModule) {
@@ -180,6 +257,10 @@ func (m *Manager) registerModules() error {
 		return errors.New("commit_checker not available")
 	}
 
+	if utils.Config.ModuleConfig.RulesChecker != nil && checkermodules.AvailableModules["rules_checker"] == nil {
+		return errors.New("rules_checker not available")
+	}
+
 	for _, module := range checkermodules.AvailableModules {
 		m.register(module)
 	}
@@ -219,20 +300,21 @@ func updateMacros() {
 func (m *Manager) RetrieveConfig() error {
 	defer updateMacros()
 
-	if _, err := os.Stat(utils.UserConfigPath); err == nil {
+	path := utils.FindUserConfig()
+
+	if _, err := os.Stat(path); err == nil {
 		// Read the config from there
-		data, err := os.ReadFile(utils.UserConfigPath)
+		data, err := os.ReadFile(path)
 		if err != nil {
 			return err
 		}
 
-		// Bug: if fields are not present, they get changed to ""
-		utils.Config.UserConfig, err = utils.NewUserConfig(string(data))
-		if err != nil {
+		// The keys missing from the file keep their default values
+		if err := utils.LoadUserConfigFile(string(data), path); err != nil {
 			return err
 		}
 	} else {
-		f, err := os.Create(utils.UserConfigPath)
+		f, err := os.Create(path)
 		if err != nil {
 			return err
 		}
@@ -243,6 +325,9 @@ func (m *Manager) RetrieveConfig() error {
 		if err != nil {
 			return err
 		}
+
+		// Keeps the comments of the starter file when it's saved
+		return utils.LoadUserConfigFile(utils.Config.DefaultUserConfig, path)
 	}
 	return nil
 }
@@ -311,6 +396,7 @@ func (m *Manager) Run() error {
 	utils.Log("launched new run")
 
 	m.checkCapabilities()
+	m.applyModuleFilter()
 
 	if _, err := exec.LookPath(utils.Config.ExecutablePath); err != nil {
 		for _, module := range m.Modules {
@@ -329,6 +415,8 @@ func (m *Manager) Run() error {
 	}
 
 	start := time.Now()
+	utils.ResetRuns()
+	m.resetDurations()
 
 	// Make sure temp path exists
 	tempPath, err := filepath.Abs(utils.Config.TempPath)
@@ -372,15 +460,21 @@ func (m *Manager) Run() error {
 				defer wg.Done()
 				defer utils.Log(module.GetName() + " done!")
 				if module.GetStatus() == checkermodules.Queued {
-					module.Run()
+					m.runModule(module)
 				}
 			}()
 		}
 	}
 
 	var ranTests int32
+	selectedTests := 0
 
 	for i, test := range utils.Config.Tests {
+		if !utils.TestSelected(test) {
+			continue
+		}
+		selectedTests++
+
 		wg.Add(1)
 		go func() {
 			defer func() { wg.Done(); atomic.AddInt32(&ranTests, 1) }()
@@ -388,11 +482,18 @@ func (m *Manager) Run() error {
 			// Create Context macros
 			contextMacros := map[string]string{
 				"FILE": test.File,
-				"IN":   fmt.Sprintf("%s/%s.in", utils.ConfigMacros["IN_DIR"], test.File),
+				"IN":   test.InputFile(),
 				"OUT":  fmt.Sprintf("%s/%s.out", utils.ConfigMacros["OUT_DIR"], test.File),
 				"N":    strconv.Itoa(i),
 			}
 
+			work, err := newScratch(tempPath, test, contextMacros)
+			if err != nil {
+				utils.Err(fmt.Sprintf("%s: %s", test.File, err))
+				return // err
+			}
+			defer work.remove()
+
 			var processedArgs []string
 
 			// Process args
@@ -402,7 +503,20 @@ func (m *Manager) Run() error {
 
 			var cmd *exec.Cmd
 
-			if m.capabilities["valgrind"] && utils.Config.RunValgrind {
+			ctx, cancel := context.Background(), context.CancelFunc(func() {})
+			if m.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, m.Timeout)
+			}
+			defer cancel()
+
+			limits := utils.LimitsOf(test)
+			valgrind := m.capabilities["valgrind"] && utils.Config.RunValgrind
+			if valgrind {
+				// Valgrind reserves far more address space than the test uses
+				limits.Memory = 0
+			}
+
+			if valgrind {
 
 				xmlPath := filepath.Join(tempPath, fmt.Sprintf("%s.xml", test.File))
 
@@ -418,37 +532,67 @@ func (m *Manager) Run() error {
 					fmt.Sprintf("--xml-file=%s", xmlPath),
 				}
 
-				cmd = exec.Command("valgrind", append(append(valgrindArgs, execPath), processedArgs...)...) //nolint:gosec
+				cmd = exec.CommandContext(ctx, "valgrind", append(append(valgrindArgs, execPath), processedArgs...)...) //nolint:gosec
 				// fmt.Println("running: valgrind " + strings.Join(append(append(valgrindArgs, execPath), processedArgs...), " "))
 			} else {
-				cmd = exec.Command(utils.Config.ExecutablePath, processedArgs...) //nolint:gosec
+				cmd = exec.CommandContext(ctx, commandPath(utils.Config.ExecutablePath), processedArgs...) //nolint:gosec
 			}
 
+			cmd.Dir = work.cwd
+			cmd.Env = work.env
+			limitCommand(cmd, limits)
+
 			// fmt.Printf("%d: %s %s\n\n", i+1, utils.Config.ExecutablePath, strings.Join(processedArgs, " "))
 
-			var stdout, stderr bytes.Buffer
-			cmd.Stdout = &stdout
-			cmd.Stderr = &stderr
+			stdout, stderr := newCappedBuffer(limits.Output), newCappedBuffer(limits.Output)
+			cmd.Stdout = stdout
+			cmd.Stderr = stderr
 
-			start = time.Now()
+			testStart := time.Now()
 
 			if err := cmd.Run(); err != nil {
 				utils.Err("Error running " + test.File)
 			}
 
+			timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+			if timedOut {
+				utils.Err(fmt.Sprintf("%s timed out after %s", test.File, m.Timeout))
+			}
+
+			limit := ""
+			switch {
+			case timedOut:
+				limit = utils.LimitTime
+			case stdout.exceeded || stderr.exceeded:
+				limit = utils.LimitOutput
+			default:
+				limit = runLimit(limits, cmd.ProcessState)
+			}
+			if limit != "" && !timedOut {
+				utils.Err(fmt.Sprintf("%s: %s", test.File, utils.LimitMessage(limit)))
+			}
+
+			utils.RecordRun(test.File, utils.TestRun{
+				Duration:   time.Since(testStart),
+				ExitCode:   cmd.ProcessState.ExitCode(),
+				TimedOut:   timedOut,
+				Limit:      limit,
+				PeakMemory: peakMemory(cmd.ProcessState),
+			})
+
 			// Forward stdout
-			if err := forwardBytes(stdout, fmt.Sprintf("%s.stdout", test.File)); err != nil {
+			if err := forwardBytes(stdout.buf, fmt.Sprintf("%s.stdout", test.File)); err != nil {
 				utils.Err(fmt.Sprintf("failed forwarding stdout %s", test.File))
 				return // err
 			}
 
 			// Forward stderr
-			if err := forwardBytes(stderr, fmt.Sprintf("%s.stderr", test.File)); err != nil {
+			if err := forwardBytes(stderr.buf, fmt.Sprintf("%s.stderr", test.File)); err != nil {
 				utils.Err(fmt.Sprintf("failed forwarding stderr %s", test.File))
 				return // err
 			}
 
-			utils.Log(fmt.Sprintf("[%s] %s", time.Since(start).String(), test.File))
+			utils.Log(fmt.Sprintf("[%s] %s", time.Since(testStart).String(), test.File))
 
 		}()
 	}
@@ -467,7 +611,7 @@ func (m *Manager) Run() error {
 		for updateDisplay {
 			builder := strings.Builder{}
 			builder.WriteString("[")
-			filled := int(math.Ceil(float64(ranTests) / float64(len(utils.Config.Tests)) * barLength))
+			filled := int(math.Ceil(float64(ranTests) / float64(selectedTests) * barLength))
 			for i := 0; i < filled; i++ {
 				builder.WriteString("#")
 			}
@@ -483,6 +627,7 @@ func (m *Manager) Run() error {
 
 	wg.Wait()
 	m.Check()
+	m.Elapsed = time.Since(start)
 	updateDisplay = false
 	utils.Log("finished updating display")
 	if m.StatusPing != nil {
@@ -501,7 +646,7 @@ func (m *Manager) Check() {
 			go func() {
 				defer wg.Done()
 				if module.GetStatus() == checkermodules.Queued {
-					module.Run()
+					m.runModule(module)
 				}
 			}()
 		}
@@ -510,11 +655,140 @@ func (m *Manager) Check() {
 	wg.Wait()
 }
 
+// Runs the module, keeping track of how long it took
+func (m *Manager) runModule(module checkermodules.CheckerModule) {
+	start := time.Now()
+	module.Run()
+
+	m.durationsMu.Lock()
+	defer m.durationsMu.Unlock()
+	m.durations[module.GetName()] = time.Since(start)
+}
+
+func (m *Manager) resetDurations() {
+	m.durationsMu.Lock()
+	defer m.durationsMu.Unlock()
+	m.durations = make(map[string]time.Duration)
+}
+
+// ModuleDuration returns how long the module took during the last run
+func (m *Manager) ModuleDuration(name string) time.Duration {
+	m.durationsMu.Lock()
+	defer m.durationsMu.Unlock()
+	return m.durations[name]
+}
+
+// IsVoided reports whether a module invalidated the whole grade
+func (m *Manager) IsVoided() bool {
+	for _, module := range m.Modules {
+		if voider, ok := module.(checkermodules.GradeVoider); ok && voider.VoidsGrade() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Lateness returns how late the work was submitted, 0 if it's on time
+func (m *Manager) Lateness() time.Duration {
+	for _, module := range m.Modules {
+		if submitter, ok := module.(checkermodules.LateSubmitter); ok && submitter.Lateness() > 0 {
+			return submitter.Lateness()
+		}
+	}
+
+	return 0
+}
+
+// LatePenalty returns the points deducted from the total for the late submission
+func (m *Manager) LatePenalty() int {
+	if utils.Config.LatePenalty == nil {
+		return 0
+	}
+
+	return utils.Config.LatePenalty.Deduction(m.Lateness())
+}
+
+// LateSummary describes the late submission, empty when submitted on time
+func (m *Manager) LateSummary() string {
+	lateness := m.Lateness()
+	if lateness <= 0 {
+		return ""
+	}
+
+	if utils.Config.LatePenalty == nil {
+		return fmt.Sprintf("Submitted %d hours late", int(math.Ceil(lateness.Hours())))
+	}
+
+	unit := "hours"
+	if utils.Config.LatePenalty.Per == "day" {
+		unit = "days"
+	}
+
+	return fmt.Sprintf("Submitted %d %s late (-%d points)", utils.Config.LatePenalty.LateUnits(lateness), unit, m.LatePenalty())
+}
+
 func (m *Manager) TotalScore() int {
+	if m.IsVoided() {
+		return 0
+	}
+
 	var total int
 	for _, module := range m.Modules {
 		total += module.Score()
 	}
 
-	return total
+	return max(total-m.LatePenalty(), 0)
+}
+
+// Process exit codes of the non-interactive mode.
+// 2 is left out since the flag package uses it for usage errors
+const (
+	ExitSuccess        = 0
+	ExitInfrastructure = 1 // missing executable, dependency or fatal error
+	ExitTestsFailed    = 3
+	ExitBelowThreshold = 4
+	ExitTimeout        = 5
+)
+
+// ExitCode sums up the last run. Infrastructure errors always win. With a positive minScore
+// the threshold alone decides between a pass and ExitBelowThreshold, the failed and timed
+// out tests only lowering the total; without one the most severe test outcome wins
+func (m *Manager) ExitCode(minScore int) int {
+	timedOut, failed := false, false
+
+	for _, module := range m.Modules {
+		switch module.GetStatus() {
+		case checkermodules.DependencyFail, checkermodules.Panic:
+			return ExitInfrastructure
+		}
+
+		// The disabled modules have no verdicts, e.g. MEMORY without valgrind
+		if module.GetStatus() != checkermodules.Ready {
+			continue
+		}
+
+		for _, test := range module.Report().Tests {
+			switch test.Verdict {
+			case checkermodules.VerdictPassed, checkermodules.VerdictSkipped:
+			case checkermodules.VerdictTimeout:
+				timedOut = true
+			default:
+				failed = true
+			}
+		}
+	}
+
+	switch {
+	case minScore > 0 && m.TotalScore() < minScore:
+		return ExitBelowThreshold
+	case minScore > 0:
+		return ExitSuccess
+	case timedOut:
+		return ExitTimeout
+	case failed:
+		return ExitTestsFailed
+	}
+
+	return ExitSuccess
 }
@@ -0,0 +1,123 @@
+package manager
+
+import (
+	"checker-pa/src/utils"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// The directory a test runs in, removed once the test is done
+type scratch struct {
+	dir string
+	cwd string
+	env []string
+}
+
+// Copies the file or the directory into the directory, under its own name
+func copyInto(src string, dir string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	dst := filepath.Join(dir, filepath.Base(src))
+
+	if info.IsDir() {
+		return os.CopyFS(dst, os.DirFS(src))
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// Creates the scratch directory of the test with its files, setting the $WORK macro to it.
+// Tests running in parallel each get their own, so relative paths never collide
+func newScratch(tempPath string, test utils.Test, contextMacros map[string]string) (*scratch, error) {
+	dir, err := os.MkdirTemp(tempPath, test.File+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed creating the scratch directory: %w", err)
+	}
+
+	s := &scratch{dir: dir, cwd: dir}
+	contextMacros["WORK"] = dir
+
+	for _, file := range test.Files {
+		path, err := utils.ExpandMacrosErr(file, contextMacros)
+		if err == nil {
+			err = copyInto(path, dir)
+		}
+		if err != nil {
+			s.remove()
+			return nil, fmt.Errorf("failed copying %s: %w", file, err)
+		}
+	}
+
+	if test.Cwd != "" {
+		cwd, err := utils.ExpandMacrosErr(test.Cwd, contextMacros)
+		if err != nil {
+			s.remove()
+			return nil, err
+		}
+
+		if !filepath.IsAbs(cwd) {
+			cwd = filepath.Join(dir, cwd)
+		}
+		if err := os.MkdirAll(cwd, 0777); err != nil {
+			s.remove()
+			return nil, err
+		}
+		s.cwd = cwd
+	}
+
+	if len(test.Env) > 0 {
+		s.env = os.Environ()
+		for _, key := range slices.Sorted(maps.Keys(test.Env)) {
+			value, err := utils.ExpandMacrosErr(test.Env[key], contextMacros)
+			if err != nil {
+				s.remove()
+				return nil, fmt.Errorf("env %s: %w", key, err)
+			}
+			s.env = append(s.env, key+"="+value)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *scratch) remove() {
+	if err := os.RemoveAll(s.dir); err != nil {
+		utils.Err(fmt.Sprintf("failed removing %s: %s", s.dir, err))
+	}
+}
+
+// Returns the path to run the executable with from another directory,
+// the names without a separator are still looked up in $PATH
+func commandPath(path string) string {
+	if !strings.ContainsRune(path, os.PathSeparator) {
+		return path
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	return abs
+}
@@ -251,6 +251,15 @@ func (m *Menu) displayMemory() {
 	checkermodules.AvailableModules["memory_checker"].Display(m.Display)
 }
 
+func (m *Menu) displayRules() {
+	m.CurrentContainer().Clear()
+	m.redraw = func() {
+		m.displayRules()
+	}
+	checkermodules.AvailableModules["rules_checker"].Display(m.Display)
+	m.CurrentContainer().WrapInput(m.CurrentContainer().Sections[0])
+}
+
 func (m *Menu) displayCommits() {
 	m.CurrentContainer().Clear()
 	m.redraw = func() {
@@ -445,6 +454,9 @@ func (m *Menu) createMainMenu() {
 	m.nav.AddItem("Commit", "", 0, func() {
 		m.displayCommits()
 	})
+	m.nav.AddItem("Rules", "", 0, func() {
+		m.displayRules()
+	})
 	m.nav.AddItem("Options", "", 0, func() {
 		m.displayOptions()
 	})
@@ -492,6 +504,12 @@ func (m *Menu) createMainMenu() {
 			}
 		}
 
+		if late := m.LateSummary(); late != "" {
+			summary.WriteString("\n" + late + "\n")
+		}
+
+		summary.WriteString("\nConfig: " + utils.Config.ModuleConfigSource + "\n")
+
 		fmt.Fprintf(tview.ANSIWriter(infoBox), "%s", summary.String())
 
 		// utils.Log("redrawing")
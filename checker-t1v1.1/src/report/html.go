@@ -0,0 +1,176 @@
+package report
+
+import (
+	"checker-pa/src/checker-modules"
+	"checker-pa/src/manager"
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"strings"
+	"time"
+)
+
+// Everything is inlined so the report can be mailed or archived as a single file
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Checker report - {{.Total}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; background: #fafafa; }
+h1 { margin-bottom: 0.2em; }
+.meta { color: #666; margin-bottom: 1.5em; }
+table { border-collapse: collapse; margin: 0.5em 0 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.7em; text-align: left; }
+th { background: #eee; }
+details { background: #fff; border: 1px solid #ccc; border-radius: 4px; margin: 0.5em 0; padding: 0.5em 1em; }
+details details { border-color: #e0e0e0; }
+summary { cursor: pointer; font-weight: bold; }
+pre { background: #f4f4f4; padding: 0.5em; overflow-x: auto; margin: 0.3em 0; }
+.ready, .passed { color: #1a7f37; }
+.failed, .missing, .error, .timeout, .memory_limit, .output_limit, .dependency_fail, .panic { color: #cf222e; }
+.disabled, .queued, .running, .skipped { color: #888; }
+.void { color: #cf222e; font-weight: bold; }
+.diff { display: flex; gap: 1em; }
+.diff > div { flex: 1; min-width: 0; }
+del { background: #ffd7d5; text-decoration: none; }
+ins { background: #ccffd8; text-decoration: none; }
+ol.stack { font-family: monospace; margin: 0.2em 0 0.8em 0; }
+</style>
+</head>
+<body>
+<h1>Score: {{.Total}}</h1>
+<div class="meta">Generated {{.GeneratedAt}} in {{.Duration}}</div>
+{{if .Late}}<p>{{.Late}}</p>{{end}}
+{{if .Voided}}<p class="void">A critical rule was broken, the grade is void!</p>{{end}}
+
+<table>
+<tr><th>Module</th><th>Status</th><th>Result</th><th>Raw score</th><th>Score</th><th>Time</th></tr>
+{{range .Modules}}<tr><td>{{.Name}}</td><td class="{{.Status}}">{{.Status}}</td><td>{{.Result}}</td><td>{{.RawScore}}</td><td>{{.Score}}</td><td>{{.Duration}}</td></tr>
+{{end}}</table>
+
+{{range .Modules}}
+<details{{if .Open}} open{{end}}>
+<summary>{{.Name}} - {{.Result}}</summary>
+{{if .Groups}}
+<table>
+<tr><th>Group</th><th>Policy</th><th>Score</th><th>Passed</th></tr>
+{{range .Groups}}<tr><td>{{.Name}}</td><td>{{.Policy}}</td><td>{{.Score}} / {{.MaxScore}}</td><td>{{.Passed}} / {{.Total}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Tests}}
+<table>
+<tr><th>Test</th><th>Group</th><th>Verdict</th><th>Score</th><th>Time</th><th>Exit code</th></tr>
+{{range .Tests}}<tr><td>{{.Name}}</td><td>{{.Group}}</td><td class="{{.Verdict}}">{{.Verdict}}</td><td>{{.Score}} / {{.MaxScore}}</td><td>{{ms .DurationMs}}</td><td>{{.ExitCode}}</td></tr>
+{{end}}</table>
+{{range .Tests}}{{if .Diff}}
+<details>
+<summary class="{{.Verdict}}">{{.Name}} ({{.File}})</summary>
+<div class="diff">
+<div>Reference<pre>{{side .Diff "delete"}}</pre></div>
+<div>Output<pre>{{side .Diff "insert"}}</pre></div>
+</div>
+</details>
+{{else if .Details}}
+<details>
+<summary class="{{.Verdict}}">{{.Name}} ({{.File}})</summary>
+<pre>{{.Details}}</pre>
+</details>
+{{end}}{{end}}
+{{end}}
+{{if .Issues}}
+<h3>Issues ({{len .Issues}})</h3>
+{{range .Issues}}<pre class="{{.Severity}}">{{.Message}}</pre>
+{{if .Stack}}<ol class="stack">{{range .Stack}}<li>{{.}}</li>{{end}}</ol>{{end}}
+{{end}}
+{{else if not .Tests}}<p>No issues found.</p>{{end}}
+</details>
+{{end}}
+</body>
+</html>
+`
+
+type htmlModule struct {
+	checkermodules.ModuleReport
+	Duration string
+	Open     bool
+}
+
+type htmlReport struct {
+	GeneratedAt string
+	Duration    string
+	Total       int
+	Voided      bool
+	Late        string
+	Modules     []htmlModule
+}
+
+// Renders one side of the diff, highlighting the chunks missing from the other one
+func diffSide(chunks []checkermodules.DiffChunk, op string) template.HTML {
+	str := strings.Builder{}
+
+	for _, chunk := range chunks {
+		text := html.EscapeString(chunk.Text)
+		switch chunk.Op {
+		case "equal":
+			str.WriteString(text)
+		case op:
+			tag := "del"
+			if op == "insert" {
+				tag = "ins"
+			}
+			str.WriteString("<" + tag + ">" + text + "</" + tag + ">")
+		}
+	}
+
+	return template.HTML(str.String()) //nolint:gosec // the chunks are escaped above
+}
+
+func formatMs(ms int64) string {
+	return fmt.Sprintf("%.3fs", float64(ms)/1000)
+}
+
+// WriteHTML exports the run as a self-contained HTML page
+func WriteHTML(path string, m *manager.Manager) error {
+	tmpl, err := template.New("report").Funcs(template.FuncMap{
+		"side": diffSide,
+		"ms":   formatMs,
+	}).Parse(htmlTemplate)
+	if err != nil {
+		return err
+	}
+
+	report := htmlReport{
+		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+		Duration:    formatMs(m.Elapsed.Milliseconds()),
+		Total:       m.TotalScore(),
+		Voided:      m.IsVoided(),
+		Late:        m.LateSummary(),
+	}
+
+	for _, module := range m.Modules {
+		moduleReport := module.Report()
+
+		open := len(moduleReport.Issues) > 0
+		for _, test := range moduleReport.Tests {
+			if test.Verdict != checkermodules.VerdictPassed {
+				open = true
+			}
+		}
+
+		report.Modules = append(report.Modules, htmlModule{
+			ModuleReport: moduleReport,
+			Duration:     formatMs(m.ModuleDuration(module.GetName()).Milliseconds()),
+			Open:         open,
+		})
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return tmpl.Execute(file, report)
+}
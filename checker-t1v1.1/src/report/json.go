@@ -0,0 +1,65 @@
+package report
+
+import (
+	"checker-pa/src/checker-modules"
+	"checker-pa/src/manager"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// Bumped whenever a field is removed or changes meaning
+const jsonReportVersion = 1
+
+type jsonReport struct {
+	Version     int                           `json:"version"`
+	GeneratedAt time.Time                     `json:"generatedAt"`
+	DurationMs  int64                         `json:"durationMs"`
+	Modules     []checkermodules.ModuleReport `json:"modules"`
+	Tests       []checkermodules.TestReport   `json:"tests"`
+	Total       int                           `json:"total"`
+	Voided      bool                          `json:"voided"`
+	Late        *jsonLateness                 `json:"late,omitempty"`
+}
+
+type jsonLateness struct {
+	Minutes int64 `json:"minutes"`
+	Penalty int   `json:"penalty"`
+}
+
+// WriteJSON exports the whole run: module results, issues and test verdicts
+func WriteJSON(path string, m *manager.Manager) error {
+	report := jsonReport{
+		Version:     jsonReportVersion,
+		GeneratedAt: time.Now(),
+		DurationMs:  m.Elapsed.Milliseconds(),
+		Modules:     []checkermodules.ModuleReport{},
+		Tests:       []checkermodules.TestReport{},
+		Total:       m.TotalScore(),
+		Voided:      m.IsVoided(),
+	}
+
+	for _, module := range m.Modules {
+		moduleReport := module.Report()
+		report.Modules = append(report.Modules, moduleReport)
+
+		// The graded verdicts come from the ref checker
+		if _, ok := module.(*checkermodules.DiffModule); ok {
+			report.Tests = append(report.Tests, moduleReport.Tests...)
+		}
+	}
+
+	if lateness := m.Lateness(); lateness > 0 {
+		report.Late = &jsonLateness{
+			Minutes: int64(lateness.Minutes()),
+			Penalty: m.LatePenalty(),
+		}
+	}
+
+	data, err := json.MarshalIndent(report, "", "	")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644) //nolint:gosec
+}
@@ -0,0 +1,203 @@
+package report
+
+import (
+	"checker-pa/src/checker-modules"
+	"checker-pa/src/manager"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+func junitTime(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
+
+// One test case per test, failing when the verdict isn't a pass
+func junitTestCases(report *checkermodules.ModuleReport) []junitTestCase {
+	var cases []junitTestCase
+
+	for _, test := range report.Tests {
+		testCase := junitTestCase{
+			Name:      test.Name,
+			ClassName: report.Name,
+			Time:      junitTime(time.Duration(test.DurationMs) * time.Millisecond),
+		}
+
+		switch test.Verdict {
+		case checkermodules.VerdictPassed:
+		case checkermodules.VerdictSkipped:
+			testCase.Skipped = &junitSkipped{Message: "left out by the test filter"}
+		case checkermodules.VerdictFailed:
+			testCase.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s failed (%d / %d)", test.File, test.Score, test.MaxScore),
+				Type:    test.Verdict,
+				Text:    test.Details,
+			}
+		default:
+			testCase.Error = &junitFailure{
+				Message: fmt.Sprintf("%s: %s", test.File, test.Verdict),
+				Type:    test.Verdict,
+				Text:    test.Details,
+			}
+		}
+
+		cases = append(cases, testCase)
+	}
+
+	return cases
+}
+
+// One test case per rule, failing with every issue reported for it
+func junitIssueCases(report *checkermodules.ModuleReport) []junitTestCase {
+	if len(report.Issues) == 0 {
+		return []junitTestCase{{Name: report.Name, ClassName: report.Name, Time: junitTime(0)}}
+	}
+
+	var rules []string
+	groups := make(map[string][]checkermodules.IssueReport)
+
+	for _, issue := range report.Issues {
+		rule := issue.RuleID
+		if rule == "" {
+			rule = strings.ToLower(report.Name)
+		}
+
+		if _, ok := groups[rule]; !ok {
+			rules = append(rules, rule)
+		}
+		groups[rule] = append(groups[rule], issue)
+	}
+
+	var cases []junitTestCase
+
+	for _, rule := range rules {
+		text := strings.Builder{}
+		for _, issue := range groups[rule] {
+			text.WriteString(issue.Message + "\n")
+		}
+
+		cases = append(cases, junitTestCase{
+			Name:      rule,
+			ClassName: report.Name,
+			Time:      junitTime(0),
+			Failure: &junitFailure{
+				Message: fmt.Sprintf("%d issues", len(groups[rule])),
+				Type:    rule,
+				Text:    text.String(),
+			},
+		})
+	}
+
+	return cases
+}
+
+func newJUnitSuite(report *checkermodules.ModuleReport, duration time.Duration) junitTestSuite {
+	suite := junitTestSuite{
+		Name: report.Name,
+		Time: junitTime(duration),
+	}
+
+	switch report.Status {
+	case "ready":
+		if len(report.Tests) > 0 {
+			suite.Cases = junitTestCases(report)
+		} else {
+			suite.Cases = junitIssueCases(report)
+		}
+	case "disabled":
+		suite.Cases = []junitTestCase{{
+			Name:      report.Name,
+			ClassName: report.Name,
+			Time:      junitTime(0),
+			Skipped:   &junitSkipped{Message: "module disabled"},
+		}}
+	default:
+		suite.Cases = []junitTestCase{{
+			Name:      report.Name,
+			ClassName: report.Name,
+			Time:      junitTime(duration),
+			Error:     &junitFailure{Message: "module did not run: " + report.Status, Type: report.Status},
+		}}
+	}
+
+	for _, testCase := range suite.Cases {
+		suite.Tests++
+		switch {
+		case testCase.Failure != nil:
+			suite.Failures++
+		case testCase.Error != nil:
+			suite.Errors++
+		case testCase.Skipped != nil:
+			suite.Skipped++
+		}
+	}
+
+	return suite
+}
+
+// WriteJUnit exports the run as a JUnit XML file, one test suite per module.
+// Ref and memory results get a test case per test, the others one per rule
+func WriteJUnit(path string, m *manager.Manager) error {
+	suites := junitTestSuites{
+		Name: "checker",
+		Time: junitTime(m.Elapsed),
+	}
+
+	for _, module := range m.Modules {
+		report := module.Report()
+		suite := newJUnitSuite(&report, m.ModuleDuration(module.GetName()))
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	data, err := xml.MarshalIndent(suites, "", "	")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append([]byte(xml.Header), data...), 0644) //nolint:gosec
+}
@@ -0,0 +1,158 @@
+package report
+
+import (
+	"checker-pa/src/checker-modules"
+	"checker-pa/src/utils"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful bool `json:"executionSuccessful"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// Maps the module severities (cppcheck, valgrind, ...) onto the SARIF levels
+func sarifLevel(issue *checkermodules.ModuleIssue) string {
+	if issue.Critical {
+		return "error"
+	}
+
+	switch issue.Severity {
+	case "error":
+		return "error"
+	case "warning":
+		return "warning"
+	case "":
+		return "none"
+	default:
+		// style, performance, portability, information
+		return "note"
+	}
+}
+
+// Returns the issue message without colors, on a single line
+func plainMessage(message string) string {
+	message = strings.TrimSpace(utils.StripANSI(message))
+	if idx := strings.Index(message, "\n"); idx != -1 {
+		message = message[:idx]
+	}
+
+	return message
+}
+
+func newSarifRun(module checkermodules.CheckerModule) sarifRun {
+	run := sarifRun{
+		Tool:        sarifTool{Driver: sarifDriver{Name: module.GetName()}},
+		Invocations: []sarifInvocation{{ExecutionSuccessful: module.GetStatus() == checkermodules.Ready}},
+		Results:     []sarifResult{},
+	}
+
+	knownRules := make(map[string]bool)
+
+	for _, issue := range module.GetIssues() {
+		if issue.RuleID != "" && !knownRules[issue.RuleID] {
+			knownRules[issue.RuleID] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: issue.RuleID})
+		}
+
+		result := sarifResult{
+			RuleID:  issue.RuleID,
+			Level:   sarifLevel(&issue),
+			Message: sarifMessage{Text: plainMessage(issue.Message)},
+		}
+
+		if issue.File != "" {
+			location := sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(filepath.Clean(issue.File))},
+			}
+			if issue.Line > 0 {
+				location.Region = &sarifRegion{StartLine: issue.Line, StartColumn: max(issue.Col, 0)}
+			}
+			result.Locations = []sarifLocation{{PhysicalLocation: location}}
+		}
+
+		run.Results = append(run.Results, result)
+	}
+
+	return run
+}
+
+// WriteSARIF exports the issues of every module as a SARIF log, one run per module
+func WriteSARIF(path string, modules []checkermodules.CheckerModule) error {
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+	}
+
+	for _, module := range modules {
+		log.Runs = append(log.Runs, newSarifRun(module))
+	}
+
+	data, err := json.MarshalIndent(log, "", "	")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644) //nolint:gosec
+}
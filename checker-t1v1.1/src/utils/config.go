@@ -1,17 +1,22 @@
 package utils
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
-	"strings"
+	"path/filepath"
+	"regexp"
 )
 
 const (
-	UserConfigPath = "./config.json"
+	UserConfigPath   = "./config.json"
+	ModuleConfigName = "module_config.json"
 )
 
+// Source of the module config when no file is found
+const EmbeddedConfigSource = "embedded"
+
 var logFile *os.File
 var logger *slog.Logger
 
@@ -20,9 +25,47 @@ var Config struct {
 	*UserConfig
 
 	DefaultUserConfig string
+
+	// Path of the module config file in use, or EmbeddedConfigSource
+	ModuleConfigSource string
+	// Path of the user config file in use
+	UserConfigSource string
+}
+
+// Returns the module config file to load: the given path, or the one next to the user config.
+// Returns an empty path when the embedded config should be used
+func findModuleConfig(path string) (string, error) {
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+
+	for _, discovered := range configCandidates(filepath.Join(filepath.Dir(UserConfigPath), ModuleConfigName)) {
+		if _, err := os.Stat(discovered); err == nil {
+			return discovered, nil
+		}
+	}
+
+	return "", nil
+}
+
+// FindUserConfig returns the user config file in use, config.json or its YAML or TOML version.
+// Returns UserConfigPath when there's none yet
+func FindUserConfig() string {
+	for _, path := range configCandidates(UserConfigPath) {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return UserConfigPath
 }
 
-func InitConfig(defaultUserConfigStr string, moduleConfigStr string) error {
+// InitConfig loads the default user config and the module config,
+// read from moduleConfigPath or next to the user config if present, embedded otherwise
+func InitConfig(defaultUserConfigStr string, moduleConfigStr string, moduleConfigPath string) error {
 	var err error
 
 	logFile, err = os.Create("./checker_log.txt")
@@ -32,38 +75,78 @@ func InitConfig(defaultUserConfigStr string, moduleConfigStr string) error {
 
 	logger = slog.New(slog.NewTextHandler(logFile, nil))
 
-	Config.UserConfig, err = NewUserConfig(defaultUserConfigStr)
-	if err != nil {
+	if err := initUserLayers(defaultUserConfigStr); err != nil {
 		return err
 	}
 
-	Config.ModuleConfig, err = newModuleConfig(moduleConfigStr)
+	Config.ModuleConfigSource = EmbeddedConfigSource
+
+	path, err := findModuleConfig(moduleConfigPath)
 	if err != nil {
 		return err
 	}
 
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		moduleConfigStr = string(data)
+		Config.ModuleConfigSource = path
+	}
+
+	Config.ModuleConfig, err = newModuleConfig(moduleConfigStr, FormatOf(path))
+
+	var invalid *ConfigErrors
+	if errors.As(err, &invalid) {
+		invalid.Source = Config.ModuleConfigSource
+		return invalid
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", Config.ModuleConfigSource, err)
+	}
+
+	Log("module config: " + Config.ModuleConfigSource)
+
 	Config.DefaultUserConfig = defaultUserConfigStr
 
 	return nil
 }
 
+// SaveUserConfig writes the keys differing from the defaults, leaving out the environment and flag overrides.
+// The comments and the formatting of an existing JSON file are kept, YAML and TOML files are rewritten
 func SaveUserConfig() {
-	f, err := os.Create(UserConfigPath)
+	overrides, err := userLayers.overrides()
 	if err != nil {
 		panic(err)
 	}
 
-	defer f.Close()
+	path := Config.UserConfigSource
+	if path == "" {
+		path = UserConfigPath
+	}
+
+	format := FormatOf(path)
 
-	newData, err := json.MarshalIndent(Config.UserConfig, "", "	")
+	var newData string
+	if format == FormatJSON {
+		newData, err = patchJSONC(userLayers.fileSource, overrides)
+	} else {
+		newData, err = ConvertConfig(marshalJSONC(overrides), FormatJSON, format)
+	}
 	if err != nil {
 		panic(err)
 	}
 
-	_, err = f.WriteString(string(newData))
-	if err != nil {
+	if err := os.WriteFile(path, []byte(newData), 0644); err != nil { //nolint:gosec
 		panic(err)
 	}
+
+	userLayers.file = overrides
+	if format == FormatJSON {
+		userLayers.fileSource = newData
+	}
 }
 
 func Log(str string) {
@@ -80,30 +163,11 @@ func Fatal(str string) {
 	os.Exit(1)
 }
 
-var ConfigMacros = make(map[string]string)
+var ansiRegex = regexp.MustCompile("\x1b\\[[0-9;]*m")
 
-func convertMacros(srcStr string, contextMacros map[string]string) string {
-	// Replace implicit macros
-	for k, v := range ConfigMacros {
-		srcStr = strings.ReplaceAll(srcStr, fmt.Sprintf("$%s", k), v)
-	}
-
-	// Replace context macros
-	for k, v := range contextMacros {
-		srcStr = strings.ReplaceAll(srcStr, fmt.Sprintf("$%s", k), v)
-	}
-
-	return srcStr
+// StripANSI removes the terminal color codes from a string
+func StripANSI(str string) string {
+	return ansiRegex.ReplaceAllString(str, "")
 }
 
-// ExpandMacros No cyclic macros please!
-func ExpandMacros(str string, contextMacros map[string]string) string {
-	lastStr := ""
-
-	for lastStr != str {
-		lastStr = str
-		str = convertMacros(str, contextMacros)
-	}
-
-	return str
-}
+var ConfigMacros = make(map[string]string)
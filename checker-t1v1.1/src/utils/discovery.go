@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The tests written in the module config, before adding the discovered ones
+var explicitTests []Test
+
+// Warnings of the last discovery, e.g. the inputs without a reference
+var DiscoveryWarnings []string
+
+// Returns the file names matched by the glob, by name without the extension
+func globNames(pattern string) (map[string]string, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("testDiscovery: invalid glob %q: %w", pattern, err)
+	}
+
+	names := make(map[string]string)
+	for _, path := range paths {
+		base := filepath.Base(path)
+		names[strings.TrimSuffix(base, filepath.Ext(base))] = path
+	}
+
+	return names, nil
+}
+
+// Compares the names with their numbers by value, so data2 comes before data10
+func naturalCompare(a string, b string) int {
+	for a != "" && b != "" {
+		aDigits := len(a) - len(strings.TrimLeft(a, "0123456789"))
+		bDigits := len(b) - len(strings.TrimLeft(b, "0123456789"))
+
+		if aDigits > 0 && bDigits > 0 {
+			aNum, _ := strconv.Atoi(a[:aDigits])
+			bNum, _ := strconv.Atoi(b[:bDigits])
+			if aNum != bNum {
+				return aNum - bNum
+			}
+			a, b = a[aDigits:], b[bDigits:]
+			continue
+		}
+
+		if a[0] != b[0] {
+			return int(a[0]) - int(b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+
+	return len(a) - len(b)
+}
+
+// InputFile returns the input of the test, absolute unless it was given relative
+func (test Test) InputFile() string {
+	if test.Input != "" {
+		return test.Input
+	}
+
+	return fmt.Sprintf("%s/%s.in", ConfigMacros["IN_DIR"], test.File)
+}
+
+// RefFile returns the reference of the test
+func (test Test) RefFile() string {
+	if test.Ref != "" {
+		return test.Ref
+	}
+
+	return fmt.Sprintf("%s/%s.ref", Config.RefPath, test.File)
+}
+
+// Returns the absolute path, as the tests run in their own directory
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+
+	return path
+}
+
+// DiscoverTests adds the tests found by the testDiscovery section to the explicit ones,
+// which override the discovered tests with the same file. Does nothing without the section
+func DiscoverTests() error {
+	if explicitTests == nil {
+		explicitTests = slices.Clone(Config.Tests)
+	}
+
+	DiscoveryWarnings = nil
+
+	discovery := Config.Discovery
+	if discovery == nil {
+		return nil
+	}
+
+	inputGlob := discovery.Input
+	if inputGlob == "" {
+		inputGlob = filepath.Join(Config.InputPath, "*.in")
+	}
+	refGlob := discovery.Ref
+	if refGlob == "" {
+		refGlob = filepath.Join(Config.RefPath, "*.ref")
+	}
+
+	inputs, err := globNames(ExpandMacros(inputGlob, nil))
+	if err != nil {
+		return err
+	}
+	refs, err := globNames(ExpandMacros(refGlob, nil))
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for name, path := range inputs {
+		if _, ok := refs[name]; ok {
+			names = append(names, name)
+		} else {
+			DiscoveryWarnings = append(DiscoveryWarnings, path+" has no reference, skipped")
+		}
+	}
+	for name, path := range refs {
+		if _, ok := inputs[name]; !ok {
+			DiscoveryWarnings = append(DiscoveryWarnings, path+" has no input, skipped")
+		}
+	}
+
+	slices.SortFunc(names, naturalCompare)
+	slices.Sort(DiscoveryWarnings)
+
+	if len(names) == 0 {
+		DiscoveryWarnings = append(DiscoveryWarnings, fmt.Sprintf("no test found by %s and %s", inputGlob, refGlob))
+	}
+
+	width := len(strconv.Itoa(len(names)))
+
+	var tests []Test
+	for i, name := range names {
+		explicit := slices.IndexFunc(explicitTests, func(test Test) bool { return test.File == name })
+		if explicit >= 0 {
+			// Still run on the discovered files, unless it names its own
+			test := explicitTests[explicit]
+			if test.Input == "" {
+				test.Input = absPath(inputs[name])
+			}
+			if test.Ref == "" {
+				test.Ref = absPath(refs[name])
+			}
+			tests = append(tests, test)
+			continue
+		}
+
+		displayName := discovery.DisplayName
+		if displayName == "" {
+			displayName = "$FILE"
+		}
+
+		tests = append(tests, Test{
+			DisplayName: ExpandMacros(displayName, map[string]string{
+				"FILE":  name,
+				"INDEX": fmt.Sprintf("%0*d", max(width, 2), i+1),
+			}),
+			File:       name,
+			Input:      absPath(inputs[name]),
+			Ref:        absPath(refs[name]),
+			Args:       slices.Clone(discovery.Args),
+			Ordered:    discovery.Ordered,
+			WhiteSpace: discovery.WhiteSpace,
+			Score:      discovery.Score,
+		})
+	}
+
+	// The explicit tests without files to discover still run
+	for _, test := range explicitTests {
+		if !slices.Contains(names, test.File) {
+			tests = append(tests, test)
+		}
+	}
+
+	Config.Tests = tests
+
+	for _, warning := range DiscoveryWarnings {
+		Err("test discovery: " + warning)
+	}
+	Log(fmt.Sprintf("test discovery: %d tests found", len(names)))
+
+	return nil
+}
@@ -0,0 +1,340 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFormat is the file format of a config, detected by its extension
+type ConfigFormat string
+
+const (
+	FormatJSON ConfigFormat = "json" // with comments
+	FormatYAML ConfigFormat = "yaml"
+	FormatTOML ConfigFormat = "toml"
+)
+
+// Extensions of the config files, in the order they're looked for
+var configExtensions = []string{".json", ".yaml", ".yml", ".toml"}
+
+// FormatOf returns the format of the config file, JSON unless the extension says otherwise
+func FormatOf(path string) ConfigFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+// Returns the paths of the config with every supported extension, e.g. config.json, config.yaml...
+func configCandidates(path string) []string {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+
+	candidates := make([]string, 0, len(configExtensions))
+	for _, ext := range configExtensions {
+		candidates = append(candidates, base+ext)
+	}
+
+	return candidates
+}
+
+type position struct {
+	line int
+	col  int
+}
+
+// Converts a yaml node to a value json can encode, recording where each path starts
+func yamlValue(node *yaml.Node, path string, positions map[string]position) (any, error) {
+	if _, ok := positions[path]; !ok {
+		positions[path] = position{node.Line, node.Column}
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlValue(node.Content[0], path, positions)
+	case yaml.AliasNode:
+		return yamlValue(node.Alias, path, positions)
+	case yaml.MappingNode:
+		mapping := make(map[string]any)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			positions[joinPath(path, key)] = position{node.Content[i].Line, node.Content[i].Column}
+
+			value, err := yamlValue(node.Content[i+1], joinPath(path, key), positions)
+			if err != nil {
+				return nil, err
+			}
+			mapping[key] = value
+		}
+		return mapping, nil
+	case yaml.SequenceNode:
+		sequence := make([]any, 0, len(node.Content))
+		for i, item := range node.Content {
+			value, err := yamlValue(item, fmt.Sprintf("%s[%d]", path, i), positions)
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, value)
+		}
+		return sequence, nil
+	default:
+		var value any
+		err := node.Decode(&value)
+		return value, err
+	}
+}
+
+// Converts the config source to JSON, so it can go through the same schema checks and decoding.
+// For the other formats, the positions of the paths in the original file are returned too
+func toJSON(source string, format ConfigFormat) (string, map[string]position, error) {
+	switch format {
+	case FormatYAML:
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(source), &doc); err != nil {
+			return "", nil, &ConfigErrors{Errors: []*ConfigError{{Message: err.Error()}}}
+		}
+
+		positions := make(map[string]position)
+		value, err := yamlValue(&doc, "", positions)
+		if err != nil {
+			return "", nil, err
+		}
+
+		data, err := json.Marshal(value)
+		return string(data), positions, err
+	case FormatTOML:
+		var value map[string]any
+		if _, err := toml.Decode(source, &value); err != nil {
+			configErr := &ConfigError{Message: err.Error()}
+
+			var parseErr toml.ParseError
+			if errors.As(err, &parseErr) {
+				configErr.Line, configErr.Col = lineCol(source, parseErr.Position.Start)
+			}
+			return "", nil, &ConfigErrors{Errors: []*ConfigError{configErr}}
+		}
+
+		data, err := json.Marshal(value)
+		return string(data), map[string]position{}, err
+	default:
+		newSource, err := stripJSONC(source)
+		return newSource, nil, err
+	}
+}
+
+// Replaces the positions in the generated JSON with the ones in the original file,
+// from the path or the closest parent found. Left empty when unknown, as for TOML
+func relocate(errs *ConfigErrors, positions map[string]position) {
+	for _, err := range errs.Errors {
+		err.Line, err.Col = 0, 0
+
+		for path := err.Path; ; {
+			if pos, ok := positions[path]; ok {
+				err.Line, err.Col = pos.line, pos.col
+				break
+			}
+
+			cut := strings.LastIndexAny(path, ".[")
+			if cut < 0 {
+				break
+			}
+			path = path[:cut]
+		}
+	}
+}
+
+// Decodes a config of any format into a value keeping the order of the keys
+func decodeOrdered(source string, format ConfigFormat) (*yaml.Node, error) {
+	if format == FormatYAML {
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(source), &doc); err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 {
+			return nil, errors.New("empty config")
+		}
+		return doc.Content[0], nil
+	}
+
+	data, _, err := toJSON(source, format)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	return jsonNode(dec)
+}
+
+// Builds the yaml node of the next json value, in the order of the file
+func jsonNode(dec *json.Decoder) (*yaml.Node, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if token == '{' {
+			node.Kind, node.Tag = yaml.MappingNode, "!!map"
+		}
+
+		for dec.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.(string)})
+			}
+
+			child, err := jsonNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+
+		_, err := dec.Token()
+		return node, err
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	default:
+		node := &yaml.Node{}
+		err := node.Encode(token)
+		if number, ok := token.(json.Number); ok {
+			node.Value, node.Tag, node.Style = number.String(), "!!int", 0
+			if strings.ContainsAny(number.String(), ".eE") {
+				node.Tag = "!!float"
+			}
+		}
+		return node, err
+	}
+}
+
+// Converts the node to plain values for the TOML encoder, which has no null
+func tomlValue(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		mapping := make(map[string]any)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i+1].Tag == "!!null" {
+				continue
+			}
+
+			value, err := tomlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			mapping[node.Content[i].Value] = value
+		}
+		return mapping, nil
+	case yaml.SequenceNode:
+		sequence := make([]any, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := tomlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, value)
+		}
+		return sequence, nil
+	default:
+		var value any
+		err := node.Decode(&value)
+		return value, err
+	}
+}
+
+// Encodes the node as JSON, keeping the order of the keys
+func writeJSONNode(w io.Writer, node *yaml.Node, indent string) error {
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, closing, step := "[", "]", 1
+		if node.Kind == yaml.MappingNode {
+			open, closing, step = "{", "}", 2
+		}
+
+		if len(node.Content) == 0 {
+			_, err := io.WriteString(w, open+closing)
+			return err
+		}
+
+		io.WriteString(w, open+"\n")
+		for i := 0; i < len(node.Content); i += step {
+			io.WriteString(w, indent+"	")
+			if step == 2 {
+				io.WriteString(w, marshalJSONC(node.Content[i].Value)+": ")
+			}
+
+			if err := writeJSONNode(w, node.Content[i+step-1], indent+"	"); err != nil {
+				return err
+			}
+
+			if i+step < len(node.Content) {
+				io.WriteString(w, ",")
+			}
+			io.WriteString(w, "\n")
+		}
+		_, err := io.WriteString(w, indent+closing)
+		return err
+	default:
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, marshalJSONC(value))
+		return err
+	}
+}
+
+// ConvertConfig translates a config file between JSON, YAML and TOML.
+// The order of the keys is kept between JSON and YAML, a TOML input or output sorts them
+// instead, with the tables last in TOML; comments are dropped
+func ConvertConfig(source string, from ConfigFormat, to ConfigFormat) (string, error) {
+	node, err := decodeOrdered(source, from)
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.Buffer{}
+
+	switch to {
+	case FormatYAML:
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(node); err != nil {
+			return "", err
+		}
+		err = enc.Close()
+	case FormatTOML:
+		value, err := tomlValue(node)
+		if err != nil {
+			return "", err
+		}
+		err = toml.NewEncoder(&buf).Encode(value)
+		if err != nil {
+			return "", err
+		}
+	default:
+		err = writeJSONNode(&buf, node, "")
+		buf.WriteString("\n")
+	}
+
+	return buf.String(), err
+}
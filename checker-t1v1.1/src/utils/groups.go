@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+)
+
+// Group of each test by file, set by ResolveGroups
+var testGroups map[string]int
+
+func (group *TestGroup) matches(test Test) bool {
+	for _, pattern := range group.Tests {
+		if matched, _ := path.Match(pattern, test.File); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResolveGroups assigns the tests to their group, once the tests are discovered.
+// Every pattern has to match a test and a test can't be in two groups
+func ResolveGroups() error {
+	testGroups = make(map[string]int)
+
+	var errs []error
+
+	for i, group := range Config.Groups {
+		for _, pattern := range group.Tests {
+			single := TestGroup{Tests: []string{pattern}}
+			if !slices.ContainsFunc(Config.Tests, single.matches) {
+				errs = append(errs, fmt.Errorf("group %s: no test matches %q", group.Name, pattern))
+			}
+		}
+
+		for _, test := range Config.Tests {
+			if !group.matches(test) {
+				continue
+			}
+
+			if other, ok := testGroups[test.File]; ok {
+				errs = append(errs, fmt.Errorf("test %s is in both groups %s and %s", test.File, Config.Groups[other].Name, group.Name))
+				continue
+			}
+			testGroups[test.File] = i
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// GroupOf returns the index of the group of the test, -1 if it isn't in any
+func GroupOf(test Test) int {
+	if group, ok := testGroups[test.File]; ok {
+		return group
+	}
+
+	return -1
+}
+
+// MaxScore returns the score of the group when every test passes
+func (group *TestGroup) MaxScore() int {
+	if group.Score > 0 {
+		return group.Score
+	}
+
+	total := 0
+	for _, test := range Config.Tests {
+		if group.matches(test) {
+			total += test.Score
+		}
+	}
+
+	return total
+}
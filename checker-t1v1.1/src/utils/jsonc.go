@@ -0,0 +1,316 @@
+package utils
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// JSON with comments: "//" and "/* */" comments and trailing commas are allowed
+
+type jsoncToken struct {
+	kind  byte // one of {}[]:, or 's' for strings and 'v' for the other literals
+	start int
+	end   int
+}
+
+type jsoncSpan struct {
+	start int
+	end   int
+}
+
+func jsoncError(source string, offset int, message string) error {
+	line, col := lineCol(source, offset)
+	return &ConfigErrors{Errors: []*ConfigError{{Line: line, Col: col, Message: message}}}
+}
+
+// Splits the source into tokens and comments, keeping their offsets
+func tokenizeJSONC(source string) ([]jsoncToken, []jsoncSpan, error) {
+	var tokens []jsoncToken
+	var comments []jsoncSpan
+
+	for i := 0; i < len(source); {
+		c := source[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case strings.HasPrefix(source[i:], "//"):
+			end := strings.IndexByte(source[i:], '\n')
+			if end < 0 {
+				end = len(source) - i
+			}
+			comments = append(comments, jsoncSpan{i, i + end})
+			i += end
+		case strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i+2:], "*/")
+			if end < 0 {
+				return nil, nil, jsoncError(source, i, "unterminated comment")
+			}
+			comments = append(comments, jsoncSpan{i, i + 2 + end + 2})
+			i += 2 + end + 2
+		case c == '/':
+			return nil, nil, jsoncError(source, i, "unexpected /, comments start with // or /*")
+		case c == '"':
+			j := i + 1
+			for ; j < len(source) && source[j] != '"'; j++ {
+				if source[j] == '\n' {
+					break
+				}
+				if source[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(source) || source[j] != '"' {
+				return nil, nil, jsoncError(source, i, "unterminated string")
+			}
+			tokens = append(tokens, jsoncToken{'s', i, j + 1})
+			i = j + 1
+		case strings.IndexByte("{}[]:,", c) >= 0:
+			tokens = append(tokens, jsoncToken{c, i, i + 1})
+			i++
+		default:
+			j := i
+			for j < len(source) && strings.IndexByte(" \t\r\n{}[]:,\"/", source[j]) < 0 {
+				j++
+			}
+			tokens = append(tokens, jsoncToken{'v', i, j})
+			i = j
+		}
+	}
+
+	return tokens, comments, nil
+}
+
+// Blanks the span out, keeping the line breaks so the positions don't move
+func blank(buf []byte, span jsoncSpan) {
+	for i := span.start; i < span.end; i++ {
+		if buf[i] != '\n' && buf[i] != '\r' {
+			buf[i] = ' '
+		}
+	}
+}
+
+// Turns the JSONC source into plain JSON of the same length,
+// so the offsets reported by the decoder still match the original file
+func stripJSONC(source string) (string, error) {
+	tokens, comments, err := tokenizeJSONC(source)
+	if err != nil {
+		return "", err
+	}
+
+	buf := []byte(source)
+	for _, comment := range comments {
+		blank(buf, comment)
+	}
+
+	for i := 1; i < len(tokens); i++ {
+		if (tokens[i].kind == '}' || tokens[i].kind == ']') && tokens[i-1].kind == ',' {
+			blank(buf, jsoncSpan{tokens[i-1].start, tokens[i-1].end})
+		}
+	}
+
+	return string(buf), nil
+}
+
+// A key of the top level object, with the offsets needed to edit it in place
+type jsoncMember struct {
+	key        string
+	keyStart   int
+	valueStart int
+	valueEnd   int
+	comma      int // offset of the following comma, -1 if there is none
+}
+
+// Returns the members of the top level object and the offset of its closing brace
+func topLevelMembers(source string, tokens []jsoncToken) ([]jsoncMember, int, bool) {
+	if len(tokens) == 0 || tokens[0].kind != '{' {
+		return nil, 0, false
+	}
+
+	var members []jsoncMember
+
+	for i := 1; i < len(tokens); {
+		if tokens[i].kind == '}' {
+			return members, tokens[i].start, true
+		}
+
+		if tokens[i].kind != 's' || i+2 >= len(tokens) || tokens[i+1].kind != ':' {
+			return nil, 0, false
+		}
+
+		member := jsoncMember{keyStart: tokens[i].start, valueStart: tokens[i+2].start, comma: -1}
+		if err := json.Unmarshal([]byte(source[tokens[i].start:tokens[i].end]), &member.key); err != nil {
+			return nil, 0, false
+		}
+
+		// Skip the value, nested objects and arrays included
+		i += 2
+		for depth := 0; i < len(tokens); i++ {
+			switch tokens[i].kind {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+			if depth == 0 {
+				break
+			}
+		}
+		if i >= len(tokens) {
+			return nil, 0, false
+		}
+
+		member.valueEnd = tokens[i].end
+		i++
+
+		if i < len(tokens) && tokens[i].kind == ',' {
+			member.comma = tokens[i].start
+			i++
+		}
+
+		members = append(members, member)
+	}
+
+	return nil, 0, false
+}
+
+type jsoncEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// Returns the end of the line if only blanks and comments follow the offset, -1 otherwise
+func restOfLine(source string, offset int, comments []jsoncSpan) int {
+	for offset < len(source) && source[offset] != '\n' {
+		if strings.IndexByte(" \t\r", source[offset]) >= 0 {
+			offset++
+			continue
+		}
+
+		i := slices.IndexFunc(comments, func(c jsoncSpan) bool { return c.start == offset })
+		if i < 0 {
+			return -1
+		}
+		offset = comments[i].end
+	}
+
+	return offset
+}
+
+func marshalJSONC(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(data)
+}
+
+// Rewrites the top level keys of the JSONC source with the given values, keeping the
+// comments and the formatting. Missing keys are removed and the new ones appended
+func patchJSONC(source string, values map[string]any) (string, error) {
+	tokens, comments, err := tokenizeJSONC(source)
+	if err != nil {
+		return "", err
+	}
+
+	members, closing, ok := topLevelMembers(source, tokens)
+	if !ok {
+		data, err := json.MarshalIndent(values, "", "	")
+		return string(data), err
+	}
+
+	newline := "\n"
+	if strings.Contains(source, "\r\n") {
+		newline = "\r\n"
+	}
+
+	indent := "	"
+	if len(members) > 0 {
+		lineStart := strings.LastIndexByte(source[:members[0].keyStart], '\n') + 1
+		if prefix := source[lineStart:members[0].keyStart]; strings.TrimSpace(prefix) == "" {
+			indent = prefix
+		}
+	}
+
+	var edits []jsoncEdit
+	present := make(map[string]bool)
+
+	for _, member := range members {
+		present[member.key] = true
+		value, keep := values[member.key]
+
+		if !keep {
+			edit := jsoncEdit{start: member.keyStart, end: member.valueEnd}
+			if member.comma >= 0 {
+				edit.end = member.comma + 1
+			}
+
+			// Drop the whole line when the member is alone on it, trailing comment included
+			lineStart := strings.LastIndexByte(source[:edit.start], '\n') + 1
+			if lineEnd := restOfLine(source, edit.end, comments); strings.TrimSpace(source[lineStart:edit.start]) == "" && lineEnd >= 0 {
+				edit.start = lineStart
+				edit.end = min(lineEnd+1, len(source))
+			}
+
+			edits = append(edits, edit)
+			continue
+		}
+
+		var old any
+		if err := json.Unmarshal([]byte(source[member.valueStart:member.valueEnd]), &old); err == nil && reflect.DeepEqual(old, value) {
+			continue
+		}
+
+		edits = append(edits, jsoncEdit{member.valueStart, member.valueEnd, marshalJSONC(value)})
+	}
+
+	var added []string
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		if !present[key] {
+			added = append(added, indent+marshalJSONC(key)+": "+marshalJSONC(values[key]))
+		}
+	}
+
+	if len(added) > 0 {
+		text := strings.Join(added, ","+newline)
+
+		if len(members) == 0 {
+			edits = append(edits, jsoncEdit{closing, closing, newline + text + newline})
+		} else {
+			last := members[len(members)-1]
+			end := last.valueEnd
+			if last.comma >= 0 {
+				end = last.comma + 1
+			}
+
+			// After the comments following the last member, so they stay on its line
+			insert := restOfLine(source, end, comments)
+			if insert < 0 {
+				insert = end
+			}
+			if insert > 0 && source[insert-1] == '\r' {
+				insert--
+			}
+
+			edits = append(edits, jsoncEdit{insert, insert, newline + text})
+			if last.comma < 0 {
+				edits = append(edits, jsoncEdit{last.valueEnd, last.valueEnd, ","})
+			}
+		}
+	}
+
+	// Backwards so the offsets stay valid, the insertions created first go last at the same offset
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+
+	for _, edit := range edits {
+		source = source[:edit.start] + edit.text + source[edit.end:]
+	}
+
+	return source, nil
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenizeJSONCStraySlash(t *testing.T) {
+	for _, source := range []string{
+		`{"a": 1 / 2}`,
+		`{"a": 1, / note
+}`,
+		`{"a": 1}/`,
+	} {
+		done := make(chan error, 1)
+		go func() {
+			_, err := stripJSONC(source)
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			var configErrs *ConfigErrors
+			if !errors.As(err, &configErrs) {
+				t.Errorf("stripJSONC(%q) = %v, want a config error", source, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("stripJSONC(%q) doesn't return", source)
+		}
+	}
+}
+
+func TestStripJSONCComments(t *testing.T) {
+	source := "{\"a\": 1, // one\n\"b\": /* two */ 2,}"
+
+	got, err := stripJSONC(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "{\"a\": 1,       \n\"b\":           2 }"
+	if got != want {
+		t.Errorf("stripJSONC(%q) = %q, want %q", source, got, want)
+	}
+}
@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Prefix of the environment variables overriding the user config, e.g. CHECKER_EXECUTABLE_PATH
+const EnvPrefix = "CHECKER_"
+
+// The user config is merged from these layers, each key missing from a layer is
+// inherited from the one below: embedded defaults, config.json, environment variables
+// and -set flags. Only the config.json layer is ever saved
+type configLayers struct {
+	defaults map[string]any
+	file     map[string]any
+	env      map[string]any
+	flags    map[string]any
+
+	fileSource string // kept to rewrite the file with its comments
+}
+
+var userLayers = configLayers{
+	file:  map[string]any{},
+	env:   map[string]any{},
+	flags: map[string]any{},
+}
+
+// Pending -set flags, applied once the defaults are known
+var flagOverrides = map[string]string{}
+
+// Converts the config into a map indexed by the json keys
+func toLayer(config any) (map[string]any, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var layer map[string]any
+	err = json.Unmarshal(data, &layer)
+	return layer, err
+}
+
+// Converts a string value to the type of the default value of the key
+func (cl *configLayers) parseValue(key string, value string) (any, error) {
+	def, ok := cl.defaults[key]
+	if !ok {
+		return nil, errors.New("unknown config key " + key)
+	}
+
+	switch def.(type) {
+	case bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: expected a boolean, got %q", key, value)
+		}
+		return parsed, nil
+	case float64:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: expected a number, got %q", key, value)
+		}
+		return parsed, nil
+	default:
+		return value, nil
+	}
+}
+
+func (cl *configLayers) readEnv() error {
+	cl.env = map[string]any{}
+
+	for key := range cl.defaults {
+		value, ok := os.LookupEnv(EnvPrefix + strings.ToUpper(key))
+		if !ok {
+			continue
+		}
+
+		parsed, err := cl.parseValue(key, value)
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", EnvPrefix, strings.ToUpper(key), err)
+		}
+		cl.env[key] = parsed
+	}
+
+	return nil
+}
+
+func (cl *configLayers) readFlags() error {
+	cl.flags = map[string]any{}
+
+	for key, value := range flagOverrides {
+		parsed, err := cl.parseValue(key, value)
+		if err != nil {
+			return err
+		}
+		cl.flags[key] = parsed
+	}
+
+	return nil
+}
+
+// Merges the layers into the active user config
+func (cl *configLayers) apply() error {
+	merged := maps.Clone(cl.defaults)
+	for _, layer := range []map[string]any{cl.file, cl.env, cl.flags} {
+		maps.Copy(merged, layer)
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+
+	var config UserConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return err
+	}
+
+	Config.UserConfig = &config
+	return nil
+}
+
+// SetFlagOverride overrides a user config key for this run only, as in -set key=value
+func SetFlagOverride(assignment string) error {
+	key, value, found := strings.Cut(assignment, "=")
+	if !found {
+		return errors.New("expected key=value, got " + assignment)
+	}
+
+	flagOverrides[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	return nil
+}
+
+// Sets the embedded defaults, the bottom layer
+func initUserLayers(defaultUserConfigStr string) error {
+	defaults, err := NewUserConfig(defaultUserConfigStr)
+	if err != nil {
+		return err
+	}
+
+	userLayers.defaults, err = toLayer(defaults)
+	if err != nil {
+		return err
+	}
+
+	if err := userLayers.readEnv(); err != nil {
+		return err
+	}
+
+	if err := userLayers.readFlags(); err != nil {
+		return err
+	}
+
+	return userLayers.apply()
+}
+
+// LoadUserConfigFile sets the layer of the user config file at path, in any format.
+// The keys missing from it keep the default values
+func LoadUserConfigFile(source string, path string) error {
+	newSource, positions, err := toJSON(source, FormatOf(path))
+
+	var invalid *ConfigErrors
+	if errors.As(err, &invalid) {
+		invalid.Source = path
+		return invalid
+	}
+	if err != nil {
+		return err
+	}
+
+	sw, err := checkSchema(newSource, reflect.TypeFor[UserConfig]())
+	if err != nil {
+		return err
+	}
+
+	if len(sw.errs.Errors) > 0 {
+		if positions != nil {
+			relocate(sw.errs, positions)
+		}
+		sw.errs.Source = path
+		return sw.errs
+	}
+
+	var file map[string]any
+	if err := json.Unmarshal([]byte(newSource), &file); err != nil {
+		return err
+	}
+
+	userLayers.file = file
+	userLayers.fileSource = ""
+	if FormatOf(path) == FormatJSON {
+		userLayers.fileSource = source
+	}
+
+	Config.UserConfigSource = path
+	return userLayers.apply()
+}
+
+// Returns the config.json layer updated with the changes made to the active config.
+// Values equal to the defaults are dropped, the ones set by the environment or the flags are kept out
+func (cl *configLayers) overrides() (map[string]any, error) {
+	current, err := toLayer(Config.UserConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	file := maps.Clone(cl.file)
+
+	for key, value := range current {
+		transient, isTransient := cl.flags[key]
+		if !isTransient {
+			transient, isTransient = cl.env[key]
+		}
+
+		// Left untouched since it was loaded
+		if isTransient && reflect.DeepEqual(value, transient) {
+			continue
+		}
+
+		if reflect.DeepEqual(value, cl.defaults[key]) {
+			delete(file, key)
+		} else {
+			file[key] = value
+		}
+	}
+
+	return file, nil
+}
+
+// Returns the user config keys, sorted
+func UserConfigKeys() []string {
+	return slices.Sorted(maps.Keys(userLayers.defaults))
+}
@@ -0,0 +1,54 @@
+package utils
+
+import "fmt"
+
+// KiB of stdout and of stderr kept when the limits don't say otherwise
+const DefaultOutputLimit = 16 * 1024
+
+// LimitsOf returns the limits of the test, its own fields taking precedence over the module ones
+func LimitsOf(test Test) Limits {
+	limits := Limits{}
+	if Config.ModuleConfig != nil && Config.Limits != nil {
+		limits = *Config.Limits
+	}
+
+	if own := test.Limits; own != nil {
+		for _, field := range []struct{ dst, src *int }{
+			{&limits.CPU, &own.CPU},
+			{&limits.Memory, &own.Memory},
+			{&limits.FileSize, &own.FileSize},
+			{&limits.Processes, &own.Processes},
+			{&limits.OpenFiles, &own.OpenFiles},
+			{&limits.Output, &own.Output},
+		} {
+			if *field.src != 0 {
+				*field.dst = *field.src
+			}
+		}
+	}
+
+	if limits.Output == 0 {
+		limits.Output = DefaultOutputLimit
+	}
+
+	return limits
+}
+
+// Reports the negative limits, path is the key of the limits in the config
+func (l *Limits) validate(errs *ConfigErrors, path string) {
+	for _, field := range []struct {
+		key   string
+		value int
+	}{
+		{"cpu", l.CPU},
+		{"memory", l.Memory},
+		{"fileSize", l.FileSize},
+		{"processes", l.Processes},
+		{"openFiles", l.OpenFiles},
+		{"output", l.Output},
+	} {
+		if field.value < 0 {
+			errs.add(fmt.Sprintf("%s.%s", path, field.key), "negative limit %d", field.value)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Functions usable in the macros as ${name:argument}, the argument is expanded first
+var macroFunctions = map[string]func(arg string) (string, error){
+	"basename": func(arg string) (string, error) { return filepath.Base(arg), nil },
+	"dirname":  func(arg string) (string, error) { return filepath.Dir(arg), nil },
+	"stem": func(arg string) (string, error) {
+		base := filepath.Base(arg)
+		return strings.TrimSuffix(base, filepath.Ext(base)), nil
+	},
+	"ext":   func(arg string) (string, error) { return filepath.Ext(arg), nil },
+	"abs":   filepath.Abs,
+	"env":   func(arg string) (string, error) { return os.Getenv(arg), nil },
+	"upper": func(arg string) (string, error) { return strings.ToUpper(arg), nil },
+	"lower": func(arg string) (string, error) { return strings.ToLower(arg), nil },
+}
+
+// MacroCycleError is returned for macros expanding to themselves
+type MacroCycleError struct {
+	Chain []string // e.g. A, B, A
+}
+
+func (mce *MacroCycleError) Error() string {
+	return "macro cycle: " + strings.Join(mce.Chain, " -> ")
+}
+
+// Expands $NAME, ${NAME}, ${NAME:-default} and ${function:argument}, "$$" is a literal "$"
+type macroExpander struct {
+	context map[string]string // take precedence over the config macros
+	config  map[string]string
+	stack   []string // macros being expanded, to detect the cycles
+	lenient bool     // undefined macros expand to nothing, used to look for cycles
+}
+
+func isMacroChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+func (me *macroExpander) lookup(name string) (string, bool) {
+	if value, ok := me.context[name]; ok {
+		return value, true
+	}
+
+	value, ok := me.config[name]
+	return value, ok
+}
+
+// Returns the longest macro name starting the text, so $IN_DIR isn't read as $IN followed by _DIR
+func (me *macroExpander) longestName(text string) string {
+	end := 0
+	for end < len(text) && isMacroChar(text[end]) {
+		end++
+	}
+
+	for ; end > 0; end-- {
+		if _, ok := me.lookup(text[:end]); ok {
+			return text[:end]
+		}
+	}
+
+	return ""
+}
+
+// Returns the index of the brace closing the one at start, -1 if there is none
+func closingBrace(str string, start int) int {
+	depth := 0
+	for i := start; i < len(str); i++ {
+		switch str[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func (me *macroExpander) expand(str string) (string, error) {
+	out := strings.Builder{}
+
+	for i := 0; i < len(str); {
+		if str[i] != '$' || i+1 >= len(str) {
+			out.WriteByte(str[i])
+			i++
+			continue
+		}
+
+		switch {
+		case str[i+1] == '$':
+			out.WriteByte('$')
+			i += 2
+		case str[i+1] == '{':
+			end := closingBrace(str, i+1)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated ${ in %q", str)
+			}
+
+			value, err := me.expandBraced(str[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(value)
+			i = end + 1
+		default:
+			name := me.longestName(str[i+1:])
+			if name == "" {
+				// Not a macro, e.g. a price or a shell variable
+				out.WriteByte('$')
+				i++
+				continue
+			}
+
+			value, err := me.expandName(name)
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(value)
+			i += 1 + len(name)
+		}
+	}
+
+	return out.String(), nil
+}
+
+// Expands the value of the macro, which may use other macros
+func (me *macroExpander) expandName(name string) (string, error) {
+	if start := slices.Index(me.stack, name); start >= 0 {
+		chain := append(slices.Clone(me.stack[start:]), name)
+		return "", &MacroCycleError{Chain: chain}
+	}
+
+	value, ok := me.lookup(name)
+	if !ok {
+		if me.lenient {
+			return "", nil
+		}
+		return "", fmt.Errorf("undefined macro %s", name)
+	}
+
+	me.stack = append(me.stack, name)
+	defer func() { me.stack = me.stack[:len(me.stack)-1] }()
+
+	return me.expand(value)
+}
+
+// Expands the body of ${...}
+func (me *macroExpander) expandBraced(body string) (string, error) {
+	end := 0
+	for end < len(body) && isMacroChar(body[end]) {
+		end++
+	}
+	name, rest := body[:end], body[end:]
+
+	switch {
+	case name == "":
+		return "", fmt.Errorf("invalid macro ${%s}", body)
+	case rest == "":
+		return me.expandName(name)
+	case strings.HasPrefix(rest, ":-"):
+		if value, ok := me.lookup(name); ok && value != "" {
+			return me.expandName(name)
+		}
+		return me.expand(rest[2:])
+	case rest[0] == ':':
+		function, ok := macroFunctions[name]
+		if !ok {
+			return "", fmt.Errorf("unknown macro function %s", name)
+		}
+
+		arg, err := me.expand(rest[1:])
+		if err != nil {
+			return "", err
+		}
+		return function(arg)
+	default:
+		return "", fmt.Errorf("invalid macro ${%s}", body)
+	}
+}
+
+// ExpandMacrosErr expands the macros of the string, the context macros take precedence over the config ones
+func ExpandMacrosErr(str string, contextMacros map[string]string) (string, error) {
+	me := macroExpander{context: contextMacros, config: ConfigMacros}
+	return me.expand(str)
+}
+
+// ExpandMacros expands the macros of the string, leaving it as is if it can't be expanded
+func ExpandMacros(str string, contextMacros map[string]string) string {
+	expanded, err := ExpandMacrosErr(str, contextMacros)
+	if err != nil {
+		Err(fmt.Sprintf("failed expanding %q: %s", str, err))
+		return str
+	}
+
+	return expanded
+}
+
+// Returns the cycle the macro is part of, if any
+func findMacroCycle(macros map[string]string, name string) error {
+	me := macroExpander{config: macros, lenient: true}
+	_, err := me.expandName(name)
+
+	var cycle *MacroCycleError
+	if errors.As(err, &cycle) {
+		return cycle
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// Limits a run can hit, as shown in the views
+const (
+	LimitTime   = "TLE"
+	LimitMemory = "MLE"
+	LimitOutput = "OLE"
+)
+
+// TestRun holds the outcome of running the executable on a test
+type TestRun struct {
+	Duration   time.Duration
+	ExitCode   int
+	TimedOut   bool
+	Limit      string // LimitTime, LimitMemory or LimitOutput when the run hit one
+	PeakMemory int64  // KiB of resident memory
+}
+
+// LimitMessage describes the limit hit by a run
+func LimitMessage(limit string) string {
+	switch limit {
+	case LimitTime:
+		return "time limit exceeded"
+	case LimitMemory:
+		return "memory limit exceeded"
+	case LimitOutput:
+		return "output limit exceeded"
+	default:
+		return ""
+	}
+}
+
+// LimitOf returns the limit hit by the last run of the test, if any
+func LimitOf(file string) string {
+	run, ok := GetRun(file)
+	if !ok {
+		return ""
+	}
+
+	return run.Limit
+}
+
+type runStore struct {
+	mu   sync.Mutex
+	runs map[string]TestRun
+}
+
+// Runs of the last manager run, indexed by the test file
+var testRuns = runStore{runs: make(map[string]TestRun)}
+
+func RecordRun(file string, run TestRun) {
+	testRuns.mu.Lock()
+	defer testRuns.mu.Unlock()
+
+	testRuns.runs[file] = run
+}
+
+func GetRun(file string) (TestRun, bool) {
+	testRuns.mu.Lock()
+	defer testRuns.mu.Unlock()
+
+	run, ok := testRuns.runs[file]
+	return run, ok
+}
+
+func ResetRuns() {
+	testRuns.mu.Lock()
+	defer testRuns.mu.Unlock()
+
+	testRuns.runs = make(map[string]TestRun)
+}
@@ -0,0 +1,343 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// ConfigError locates a problem in a config file
+type ConfigError struct {
+	Path    string // e.g. tests[2].score, empty for the whole file
+	Line    int    // starting at 1, 0 when unknown
+	Col     int
+	Message string
+}
+
+func (ce *ConfigError) Error() string {
+	str := ""
+	if ce.Line > 0 {
+		str = fmt.Sprintf("%d:%d: ", ce.Line, ce.Col)
+	}
+
+	if ce.Path != "" {
+		str += ce.Path + ": "
+	}
+
+	return str + ce.Message
+}
+
+// ConfigErrors gathers every problem found in a config file
+type ConfigErrors struct {
+	Source string
+	Errors []*ConfigError
+}
+
+func (ce *ConfigErrors) Error() string {
+	lines := make([]string, 0, len(ce.Errors))
+	for _, err := range ce.Errors {
+		lines = append(lines, ce.Source+":"+err.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (ce *ConfigErrors) add(path string, format string, args ...any) {
+	ce.Errors = append(ce.Errors, &ConfigError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Returns the errors, or nil if there are none so the result can be compared to nil
+func (ce *ConfigErrors) orNil() error {
+	if len(ce.Errors) == 0 {
+		return nil
+	}
+
+	return ce
+}
+
+var unmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// Walks the json tokens along the go type, recording where each value starts
+type schemaWalker struct {
+	source  string
+	dec     *json.Decoder
+	offsets map[string]int // path -> offset of the value
+	errs    *ConfigErrors
+}
+
+// Returns the offset of the next token, past the separators
+func (sw *schemaWalker) nextOffset() int {
+	offset := int(sw.dec.InputOffset())
+	for offset < len(sw.source) && strings.ContainsRune(" \t\r\n,:", rune(sw.source[offset])) {
+		offset++
+	}
+
+	return offset
+}
+
+func tokenKind(token json.Token) string {
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '{' {
+			return "object"
+		}
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// Skips the rest of a value whose first token was already read
+func (sw *schemaWalker) skip(token json.Token) error {
+	if delim, ok := token.(json.Delim); !ok || (delim != '{' && delim != '[') {
+		return nil
+	}
+
+	for depth := 1; depth > 0; {
+		token, err := sw.dec.Token()
+		if err != nil {
+			return err
+		}
+
+		if delim, ok := token.(json.Delim); ok {
+			if delim == '{' || delim == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns the name of the json type expected for the go type
+func expectedKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "any"
+	}
+}
+
+// Returns the fields of the struct by json key
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fields[name] = field.Type
+	}
+
+	return fields
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func (sw *schemaWalker) walk(path string, t reflect.Type) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	sw.offsets[path] = sw.nextOffset()
+
+	token, err := sw.dec.Token()
+	if err != nil {
+		return err
+	}
+
+	kind := tokenKind(token)
+	expected := expectedKind(t)
+
+	// Types decoding themselves may also be written as a string, like the rules
+	if kind == "string" && reflect.PointerTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	switch {
+	case kind == "null" || expected == "any":
+		return sw.skip(token)
+	case expected == "integer" && kind == "number":
+		if _, err := token.(json.Number).Int64(); err != nil {
+			sw.errs.add(path, "expected an integer, got %s", token)
+		}
+		return nil
+	case expected != kind:
+		sw.errs.add(path, "expected %s %s, got %s", article(expected), expected, kind)
+		return sw.skip(token)
+	case kind == "array":
+		for i := 0; sw.dec.More(); i++ {
+			if err := sw.walk(fmt.Sprintf("%s[%d]", path, i), t.Elem()); err != nil {
+				return err
+			}
+		}
+		_, err = sw.dec.Token()
+		return err
+	case kind == "object":
+		return sw.walkObject(path, t)
+	}
+
+	return nil
+}
+
+func (sw *schemaWalker) walkObject(path string, t reflect.Type) error {
+	var fields map[string]reflect.Type
+	if t.Kind() == reflect.Struct {
+		fields = jsonFields(t)
+	}
+
+	for sw.dec.More() {
+		keyOffset := sw.nextOffset()
+
+		token, err := sw.dec.Token()
+		if err != nil {
+			return err
+		}
+
+		key := token.(string)
+
+		var valueType reflect.Type
+		if fields == nil {
+			valueType = t.Elem()
+		} else if fieldType, ok := fields[key]; ok {
+			valueType = fieldType
+		} else {
+			// Points at the key rather than at its value
+			line, col := lineCol(sw.source, keyOffset)
+			sw.errs.Errors = append(sw.errs.Errors, &ConfigError{
+				Path:    joinPath(path, key),
+				Line:    line,
+				Col:     col,
+				Message: "unknown key" + suggestKey(key, fields),
+			})
+			valueType = reflect.TypeFor[any]()
+		}
+
+		if err := sw.walk(joinPath(path, key), valueType); err != nil {
+			return err
+		}
+	}
+
+	_, err := sw.dec.Token()
+	return err
+}
+
+func article(kind string) string {
+	if strings.ContainsRune("aeiou", rune(kind[0])) {
+		return "an"
+	}
+
+	return "a"
+}
+
+// Suggests the known key differing only by case or separators, the usual typos
+func suggestKey(key string, fields map[string]reflect.Type) string {
+	normalize := func(str string) string {
+		return strings.ToLower(strings.ReplaceAll(str, "_", ""))
+	}
+
+	for name := range fields {
+		if normalize(name) == normalize(key) {
+			return fmt.Sprintf(", did you mean %q?", name)
+		}
+	}
+
+	return ""
+}
+
+// Returns the line and the column of the offset, both starting at 1
+func lineCol(source string, offset int) (int, int) {
+	offset = min(offset, len(source))
+	before := source[:offset]
+
+	line := strings.Count(before, "\n") + 1
+	col := offset - strings.LastIndex(before, "\n")
+
+	return line, col
+}
+
+// Sets the position of the errors from their path, or from the closest parent found
+func (sw *schemaWalker) locate() {
+	for _, err := range sw.errs.Errors {
+		if err.Line > 0 {
+			continue
+		}
+
+		for path := err.Path; ; {
+			if offset, ok := sw.offsets[path]; ok {
+				err.Line, err.Col = lineCol(sw.source, offset)
+				break
+			}
+
+			cut := strings.LastIndexAny(path, ".[")
+			if cut < 0 {
+				break
+			}
+			path = path[:cut]
+		}
+	}
+}
+
+// Checks the config file against the go type it's decoded into.
+// The returned walker maps the paths to their position in the file
+func checkSchema(source string, t reflect.Type) (*schemaWalker, error) {
+	sw := &schemaWalker{
+		source:  source,
+		dec:     json.NewDecoder(strings.NewReader(source)),
+		offsets: make(map[string]int),
+		errs:    &ConfigErrors{},
+	}
+	sw.dec.UseNumber()
+
+	err := sw.walk("", t)
+
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, col := lineCol(source, int(syntaxErr.Offset))
+		sw.errs.Errors = append(sw.errs.Errors, &ConfigError{Line: line, Col: col, Message: syntaxErr.Error()})
+	case errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF):
+		line, col := lineCol(source, len(source))
+		sw.errs.Errors = append(sw.errs.Errors, &ConfigError{Line: line, Col: col, Message: "unexpected end of file"})
+	case err != nil:
+		return nil, err
+	}
+
+	sw.locate()
+	return sw, nil
+}
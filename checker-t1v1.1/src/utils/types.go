@@ -1,6 +1,9 @@
 package utils
 
-import "encoding/xml"
+import (
+	"encoding/json"
+	"encoding/xml"
+)
 
 type Test struct {
 	DisplayName string   `json:"displayName"`
@@ -9,19 +12,108 @@ type Test struct {
 	Ordered     bool     `json:"ordered"`
 	WhiteSpace  bool     `json:"whitespace"`
 	Score       int      `json:"score"`
+
+	// Set by the test discovery, input_path/<file>.in and ref_path/<file>.ref if empty
+	Input string `json:"input"`
+	Ref   string `json:"ref"`
+
+	// Run in a scratch directory of their own, the values may use macros
+	Env   map[string]string `json:"env"`   // added to the environment of the checker, e.g. "LC_ALL": "C"
+	Cwd   string            `json:"cwd"`   // relative to the scratch directory, which is the default
+	Files []string          `json:"files"` // copied into the scratch directory before running
+
+	Limits *Limits `json:"limits"` // override the module limits field by field
+}
+
+// Limits of the resources a test may use, zero for no limit
+type Limits struct {
+	CPU       int `json:"cpu"`       // seconds of CPU time
+	Memory    int `json:"memory"`    // MiB of address space, not applied under valgrind
+	FileSize  int `json:"fileSize"`  // MiB per written file
+	Processes int `json:"processes"` // counts every process of the user
+	OpenFiles int `json:"openFiles"`
+	Output    int `json:"output"` // KiB of stdout and of stderr kept, DefaultOutputLimit if zero
+}
+
+// TestDiscovery generates the tests from the inputs paired with a reference.
+// The name of a test is the file name of its input without the extension
+type TestDiscovery struct {
+	Input       string   `json:"input"`       // glob of the inputs, input_path/*.in if empty; macros are expanded
+	Ref         string   `json:"ref"`         // glob of the references, ref_path/*.ref if empty
+	DisplayName string   `json:"displayName"` // $FILE is the test name and $INDEX its position, e.g. "Test $INDEX"
+	Args        []string `json:"args"`
+	Ordered     bool     `json:"ordered"`
+	WhiteSpace  bool     `json:"whitespace"`
+	Score       int      `json:"score"`
+}
+
+// Scoring policies of the test groups
+const (
+	PolicySum          = "sum"            // the group score is shared by the passed tests
+	PolicyAllOrNothing = "all-or-nothing" // the group score only if every test passes
+)
+
+// TestGroup scores a set of tests together, like the subtasks of a contest.
+// The tests of a group don't count on their own
+type TestGroup struct {
+	Name   string   `json:"name"`
+	Tests  []string `json:"tests"`  // files of the tests, globs allowed, e.g. "data1*"
+	Score  int      `json:"score"`  // the sum of the test scores if 0
+	Policy string   `json:"policy"` // sum if empty
+}
+
+// DiffView configures the diffs printed for the failed tests
+type DiffView struct {
+	Style    string `json:"style"`    // unified or side-by-side
+	Context  int    `json:"context"`  // unchanged lines around the changes
+	Color    bool   `json:"color"`    // still off when the output isn't a terminal
+	MaxLines int    `json:"maxLines"` // truncate longer diffs, 0 for no limit
+	Width    int    `json:"width"`    // total width of the side-by-side view
 }
 
 type RefChecker struct {
-	OutputDependent bool    `json:"output_dependent"`
-	Grade           float32 `json:"grade"`
+	OutputDependent bool      `json:"output_dependent"`
+	Grade           float32   `json:"grade"`
+	Diff            *DiffView `json:"diff"`
+}
+
+// CommitPolicy describes the conventional commit rules enforced when useFormat is set
+type CommitPolicy struct {
+	Types          []string `json:"types"`  // allowed types, empty allows any
+	Scopes         []string `json:"scopes"` // allowed scopes, empty allows any
+	RequireScope   bool     `json:"requireScope"`
+	MinSubject     int      `json:"minSubject"`
+	MaxSubject     int      `json:"maxSubject"` // 0 for no limit
+	ImperativeMood bool     `json:"imperativeMood"`
+	Forbidden      []string `json:"forbidden"` // rejected subjects, e.g. "update"
+}
+
+// CommitHistory holds the thresholds of the history heuristics, zero disables a heuristic
+type CommitHistory struct {
+	MaxCommitShare    float32  `json:"maxCommitShare"`    // max share of the changed lines in a single commit (0-1)
+	AllowedAuthors    []string `json:"allowedAuthors"`    // author names or emails, empty allows any
+	MinHistoryMinutes int      `json:"minHistoryMinutes"` // min time between the first and the last commit
+}
+
+// RepoHygiene configures the checks for committed binaries and build artifacts
+type RepoHygiene struct {
+	MaxFileSize  int `json:"maxFileSize"`  // KB, 0 for no limit
+	Deduction    int `json:"deduction"`    // points deducted for each offending path
+	MaxDeduction int `json:"maxDeduction"` // 0 for no cap
 }
 
 type CommitChecker struct {
-	Dependencies    []string `json:"dependencies"`
-	OutputDependent bool     `json:"output_dependent"`
-	MinCommits      int      `json:"minCommits"`
-	UseFormat       bool     `json:"useFormat"`
-	Grade           float32  `json:"grade"`
+	Dependencies    []string       `json:"dependencies"`
+	OutputDependent bool           `json:"output_dependent"`
+	Ref             string         `json:"ref"`   // branch or ref to inspect, empty for all of them
+	Paths           []string       `json:"paths"` // relative to the source path, empty for the whole repository
+	Since           string         `json:"since"` // ignore the commits made before this date
+	MinCommits      int            `json:"minCommits"`
+	UseFormat       bool           `json:"useFormat"`
+	Policy          *CommitPolicy  `json:"policy"`
+	History         *CommitHistory `json:"history"`
+	Hygiene         *RepoHygiene   `json:"hygiene"`
+	Grade           float32        `json:"grade"`
 }
 
 type MemoryChecker struct {
@@ -42,17 +134,62 @@ type StyleChecker struct {
 	ScoreThreshold  int              `json:"score_threshold"`
 	Grade           float32          `json:"grade"`
 	Thresholds      []StyleThreshold `json:"thresholds"`
+	MaxSuppressions *int             `json:"maxSuppressions"` // suppressions over this count as issues, nil for no cap
+}
+
+// Rule describes a single entry of the rules checker lists.
+// It can also be written as a plain string, in which case it's used as the name
+type Rule struct {
+	Name     string `json:"name"`    // identifier or header name
+	Pattern  string `json:"pattern"` // regex used instead of the name
+	Per      string `json:"per"`     // required only: name must appear once per occurrence of this identifier
+	Message  string `json:"message"`
+	Critical bool   `json:"critical"` // a violation zeroes the whole grade
+}
+
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*r = Rule{Name: name}
+		return nil
+	}
+
+	// Alias to avoid recursing into this method
+	type rule Rule
+	return json.Unmarshal(data, (*rule)(r))
+}
+
+type RulesChecker struct {
+	OutputDependent   bool    `json:"output_dependent"`
+	Penalty           int     `json:"penalty"` // points deducted for each violation
+	Grade             float32 `json:"grade"`
+	BannedIdentifiers []Rule  `json:"bannedIdentifiers"`
+	BannedHeaders     []Rule  `json:"bannedHeaders"`
+	Required          []Rule  `json:"required"`
+}
+
+// LatePenalty describes the points deducted from the total for late submissions
+type LatePenalty struct {
+	Points float32 `json:"points"` // deducted for each started unit
+	Per    string  `json:"per"`    // "hour" or "day"
+	Max    float32 `json:"max"`    // cap of the deduction, 0 for none
 }
 
 type ModuleConfig struct {
-	TempPath string            `json:"temp_path"`
-	Macros   map[string]string `json:"macros"`
-	Tests    []Test            `json:"tests"`
+	TempPath    string            `json:"temp_path"`
+	Macros      map[string]string `json:"macros"`
+	Tests       []Test            `json:"tests"`
+	Discovery   *TestDiscovery    `json:"testDiscovery"`
+	Groups      []TestGroup       `json:"groups"`
+	Deadline    string            `json:"deadline"` // RFC3339 or "2006-01-02 15:04" in local time
+	LatePenalty *LatePenalty      `json:"latePenalty"`
+	Limits      *Limits           `json:"limits"` // of every test
 
 	*RefChecker    `json:"ref_checker"`
 	*CommitChecker `json:"commit_checker"`
 	*MemoryChecker `json:"memory_checker"`
 	*StyleChecker  `json:"style_checker"`
+	*RulesChecker  `json:"rules_checker"`
 }
 
 type UserConfig struct {
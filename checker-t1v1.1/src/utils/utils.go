@@ -2,37 +2,289 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
 	"math"
+	"path"
+	"reflect"
 	"regexp"
 	"slices"
+	"strings"
+	"time"
 )
 
-var commentRegex = regexp.MustCompile("//.*")
-
 func NewUserConfig(source string) (*UserConfig, error) {
 	var m UserConfig
-	newSource := commentRegex.ReplaceAllString(source, "")
-	err := json.Unmarshal([]byte(newSource), &m)
 
+	newSource, err := stripJSONC(source)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := json.Unmarshal([]byte(newSource), &m); err != nil {
+		return nil, err
+	}
+
 	return &m, nil
 }
 
-func newModuleConfig(source string) (*ModuleConfig, error) {
-	var m ModuleConfig
-	newSource := commentRegex.ReplaceAllString(source, "")
-	err := json.Unmarshal([]byte(newSource), &m)
+func newModuleConfig(source string, format ConfigFormat) (*ModuleConfig, error) {
+	newSource, positions, err := toJSON(source, format)
+	if err != nil {
+		return nil, err
+	}
 
+	sw, err := checkSchema(newSource, reflect.TypeFor[ModuleConfig]())
 	if err != nil {
 		return nil, err
 	}
 
+	var m ModuleConfig
+
+	if len(sw.errs.Errors) == 0 {
+		if err := json.Unmarshal([]byte(newSource), &m); err != nil {
+			return nil, err
+		}
+
+		var invalid *ConfigErrors
+		if errors.As(m.Validate(), &invalid) {
+			sw.errs = invalid
+			sw.locate()
+		}
+	}
+
+	if len(sw.errs.Errors) > 0 {
+		if positions != nil {
+			relocate(sw.errs, positions)
+		}
+		return nil, sw.errs
+	}
+
 	return &m, nil
 }
 
+// Validate checks that the module config can be run
+func (mc *ModuleConfig) Validate() error {
+	errs := &ConfigErrors{}
+
+	sections := []struct {
+		key     string
+		missing bool
+	}{
+		{"ref_checker", mc.RefChecker == nil},
+		{"memory_checker", mc.MemoryChecker == nil},
+		{"style_checker", mc.StyleChecker == nil},
+		{"commit_checker", mc.CommitChecker == nil},
+	}
+	for _, section := range sections {
+		if section.missing {
+			errs.add(section.key, "missing section, it's required")
+		}
+	}
+
+	if len(mc.Tests) == 0 && mc.Discovery == nil {
+		errs.add("tests", "no tests configured")
+	}
+
+	if mc.Discovery != nil && mc.Discovery.Score < 0 {
+		errs.add("testDiscovery.score", "negative score %d", mc.Discovery.Score)
+	}
+
+	if mc.Limits != nil {
+		mc.Limits.validate(errs, "limits")
+	}
+
+	files := make(map[string]int)
+	for i, test := range mc.Tests {
+		path := fmt.Sprintf("tests[%d]", i)
+
+		if test.File == "" {
+			errs.add(path, "the test has no file")
+		} else if first, ok := files[test.File]; ok {
+			errs.add(path+".file", "duplicate of tests[%d], %q is already tested", first, test.File)
+		} else {
+			files[test.File] = i
+		}
+
+		if test.Score < 0 {
+			errs.add(path+".score", "negative score %d", test.Score)
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(test.Env)) {
+			if key == "" || strings.ContainsAny(key, "=\x00") {
+				errs.add(path+".env", "invalid variable name %q", key)
+			}
+		}
+
+		for j, file := range test.Files {
+			if file == "" {
+				errs.add(fmt.Sprintf("%s.files[%d]", path, j), "empty path")
+			}
+		}
+
+		if test.Limits != nil {
+			test.Limits.validate(errs, path+".limits")
+		}
+	}
+
+	groups := make(map[string]int)
+	for i, group := range mc.Groups {
+		key := fmt.Sprintf("groups[%d]", i)
+
+		if group.Name == "" {
+			errs.add(key+".name", "the group has no name")
+		} else if first, ok := groups[group.Name]; ok {
+			errs.add(key+".name", "duplicate of groups[%d], %q is already used", first, group.Name)
+		} else {
+			groups[group.Name] = i
+		}
+
+		if len(group.Tests) == 0 {
+			errs.add(key+".tests", "the group has no tests")
+		}
+		for j, pattern := range group.Tests {
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs.add(fmt.Sprintf("%s.tests[%d]", key, j), "invalid pattern %q", pattern)
+			}
+		}
+
+		if group.Score < 0 {
+			errs.add(key+".score", "negative score %d", group.Score)
+		}
+
+		switch group.Policy {
+		case "", PolicySum, PolicyAllOrNothing:
+		default:
+			errs.add(key+".policy", "unknown policy %q, expected %s or %s", group.Policy, PolicySum, PolicyAllOrNothing)
+		}
+	}
+
+	if lp := mc.LatePenalty; lp != nil {
+		switch lp.Per {
+		case "", "hour", "day":
+		default:
+			errs.add("latePenalty.per", "unknown unit %q, expected hour or day", lp.Per)
+		}
+
+		if lp.Points < 0 {
+			errs.add("latePenalty.points", "negative points %g", lp.Points)
+		}
+		if lp.Max < 0 {
+			errs.add("latePenalty.max", "negative cap %g", lp.Max)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(mc.Macros)) {
+		if err := findMacroCycle(mc.Macros, name); err != nil {
+			errs.add("macros."+name, "%s", err)
+		}
+	}
+
+	grades := map[string]float32{}
+	if mc.RefChecker != nil {
+		grades["ref_checker"] = mc.RefChecker.Grade
+	}
+	if mc.MemoryChecker != nil {
+		grades["memory_checker"] = mc.MemoryChecker.Grade
+
+		// The score deduction is divided by it
+		if mc.MaxWarning <= 0 {
+			errs.add("memory_checker.maxWarnings", "must be positive, got %d", mc.MaxWarning)
+		}
+		if mc.MaxLeak < 0 {
+			errs.add("memory_checker.maxLeak", "negative leak size %d", mc.MaxLeak)
+		}
+	}
+	if mc.StyleChecker != nil {
+		grades["style_checker"] = mc.StyleChecker.Grade
+
+		for i, threshold := range mc.StyleChecker.Thresholds {
+			if threshold.Score < 0 {
+				errs.add(fmt.Sprintf("style_checker.thresholds[%d].score", i), "negative score %d", threshold.Score)
+			}
+		}
+		if limit := mc.StyleChecker.MaxSuppressions; limit != nil && *limit < 0 {
+			errs.add("style_checker.maxSuppressions", "negative cap %d, leave it out for no cap", *limit)
+		}
+	}
+	if mc.CommitChecker != nil {
+		grades["commit_checker"] = mc.CommitChecker.Grade
+
+		// The score deduction is divided by it
+		if mc.MinCommits <= 0 {
+			errs.add("commit_checker.minCommits", "must be positive, got %d", mc.MinCommits)
+		}
+	}
+	if mc.RulesChecker != nil {
+		grades["rules_checker"] = mc.RulesChecker.Grade
+
+		if mc.Penalty < 0 {
+			errs.add("rules_checker.penalty", "negative penalty %d", mc.Penalty)
+		}
+
+		for _, list := range []struct {
+			key   string
+			rules []Rule
+		}{
+			{"bannedIdentifiers", mc.BannedIdentifiers},
+			{"bannedHeaders", mc.BannedHeaders},
+			{"required", mc.Required},
+		} {
+			for i, rule := range list.rules {
+				if _, err := regexp.Compile(rule.Pattern); err != nil {
+					errs.add(fmt.Sprintf("rules_checker.%s[%d].pattern", list.key, i), "invalid pattern %q: %s", rule.Pattern, err)
+				}
+			}
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(grades)) {
+		if grades[key] < 0 {
+			errs.add(key+".grade", "negative grade %g", grades[key])
+		}
+	}
+
+	return errs.orNil()
+}
+
+// ParseDeadline parses the deadline from the module config
+func ParseDeadline(deadline string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, deadline); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02 15:04", deadline, time.Local); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, errors.New("invalid deadline \"" + deadline + "\", expected RFC3339 or \"YYYY-MM-DD hh:mm\"")
+}
+
+// LateUnits returns the number of started hours or days in the given lateness
+func (lp *LatePenalty) LateUnits(lateness time.Duration) int {
+	unit := time.Hour
+	if lp.Per == "day" {
+		unit = 24 * time.Hour
+	}
+
+	return int(math.Ceil(float64(lateness) / float64(unit)))
+}
+
+// Deduction returns the points deducted for the given lateness
+func (lp *LatePenalty) Deduction(lateness time.Duration) int {
+	if lateness <= 0 {
+		return 0
+	}
+
+	deduction := float32(lp.LateUnits(lateness)) * lp.Points
+	if lp.Max > 0 && deduction > lp.Max {
+		deduction = lp.Max
+	}
+
+	return int(math.Ceil(float64(deduction)))
+}
+
 type factorization struct {
 	A int
 	B int
@@ -91,3 +343,49 @@ func ComputeBestArea(size int) (int, int) {
 	return facts[0].A, facts[0].B
 
 }
+
+// FindTest returns the index of the test with the given file or display name, -1 if there's none
+func FindTest(name string) int {
+	return slices.IndexFunc(Config.Tests, func(test Test) bool {
+		return test.File == name || test.DisplayName == name
+	})
+}
+
+// Patterns selecting the tests to run, every test is selected when empty
+var testFilter []string
+
+// SetTestFilter restricts the runs to the tests whose file or display name
+// matches one of the glob patterns
+func SetTestFilter(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.New("invalid test pattern \"" + pattern + "\"")
+		}
+	}
+
+	testFilter = patterns
+
+	if len(patterns) > 0 && !slices.ContainsFunc(Config.Tests, TestSelected) {
+		return errors.New("no test matches " + strings.Join(patterns, ", "))
+	}
+
+	return nil
+}
+
+// TestSelected reports whether the test is part of the run
+func TestSelected(test Test) bool {
+	if len(testFilter) == 0 {
+		return true
+	}
+
+	for _, pattern := range testFilter {
+		if matched, _ := path.Match(pattern, test.File); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, test.DisplayName); matched {
+			return true
+		}
+	}
+
+	return false
+}